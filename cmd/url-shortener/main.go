@@ -1,20 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/natefinch/lumberjack"
 	"github.com/rs/cors"
 
 	v1 "github.com/4aykovski/url_shortener/internal/adapters/http-server/v1"
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler"
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/middleware"
 	"github.com/4aykovski/url_shortener/internal/adapters/repository/postgres"
 	"github.com/4aykovski/url_shortener/internal/config"
 	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/internal/workers"
+	"github.com/4aykovski/url_shortener/pkg/aliasgen"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/geoip"
 	"github.com/4aykovski/url_shortener/pkg/hasher"
 	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
 	"github.com/4aykovski/url_shortener/pkg/manager/token"
+	"github.com/4aykovski/url_shortener/pkg/ratelimit"
+	"github.com/4aykovski/url_shortener/pkg/revocation"
+	"github.com/4aykovski/url_shortener/pkg/secret"
 )
 
 const (
@@ -28,6 +41,9 @@ func main() {
 	// init config: cleanenv
 	cfg := config.MustLoad()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// init logger: slog ? grafana ? kibana ? grep
 	log := setupLogger(cfg.Env)
 	log.Info("starting url-shortener", slog.String("env", cfg.Env))
@@ -42,28 +58,156 @@ func main() {
 	}
 
 	// init repositories
-	urlRepo := postgres.NewUrlRepository(pq)
+	urlRepo := postgres.NewUrlRepository(pq, cfg.AliasPolicy.CaseInsensitive)
 	userRepo := postgres.NewUserRepository(pq)
 	refreshRepo := postgres.NewRefreshSessionRepository(pq)
+	clickRepo := postgres.NewClickRepository(pq)
+	utmTemplateRepo := postgres.NewUtmTemplateRepository(pq)
+	securityEventRepo := postgres.NewSecurityEventRepository(pq)
+	tokenRevocationRepo := postgres.NewTokenRevocationRepository(pq)
 
 	// init additional stuff
+	// every purpose gets its own key derived from SECRET, so they can't be recovered from each other
+	var (
+		jwtSecret    = secret.Derive(cfg.Secret, secret.PurposeJWT)
+		unlockSecret = secret.Derive(cfg.Secret, secret.PurposeURLUnlock)
+		visitorSalt  = secret.Derive(cfg.Secret, secret.PurposeVisitorHash)
+	)
+
 	h := hasher.NewBcryptHasher()
-	tM := token.NewManager(cfg.Secret)
+	tM := token.NewManager(jwtSecret)
+	if cfg.JWTKeys.ManifestFile != "" {
+		keys, err := token.LoadKeySet(cfg.JWTKeys.ManifestFile, cfg.JWTKeys.GracePeriod)
+		if err != nil {
+			log.Error("failed to load jwt keys", slogHelper.Err(err))
+			os.Exit(1)
+		}
+
+		tM = token.NewManagerWithKeys(jwtSecret, keys, cfg.JWTKeys.HS256Until)
+	}
+
+	aliasGenerator, err := aliasgen.New(cfg.Alias.Strategy, aliasgen.Options{
+		Length:   cfg.Alias.Length,
+		Alphabet: cfg.Alias.Alphabet,
+		Salt:     cfg.Alias.Salt,
+		Words:    cfg.Alias.Words,
+	}, urlRepo)
+	if err != nil {
+		log.Error("failed to init alias generator", slogHelper.Err(err))
+		os.Exit(1)
+	}
+
+	var blockedWords []string
+	if cfg.AliasPolicy.BlockedWordsFile != "" {
+		blockedWords, err = aliaspolicy.LoadWords(cfg.AliasPolicy.BlockedWordsFile)
+		if err != nil {
+			log.Error("failed to load blocked alias words", slogHelper.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	aliasPolicy, err := aliaspolicy.New(aliaspolicy.Options{
+		MinLength: cfg.AliasPolicy.MinLength,
+		MaxLength: cfg.AliasPolicy.MaxLength,
+		Charset:   cfg.AliasPolicy.Charset,
+		Reserved:  append(aliaspolicy.DefaultReserved, cfg.AliasPolicy.ReservedWords...),
+		Blocked:   blockedWords,
+	})
+	if err != nil {
+		log.Error("failed to init alias policy", slogHelper.Err(err))
+		os.Exit(1)
+	}
+
+	inactivePage := handler.InactivePage{FallbackURL: cfg.InactiveURL.FallbackURL}
+	if cfg.InactiveURL.PlaceholderFile != "" {
+		inactivePage.Placeholder, err = handler.ParsePlaceholder(cfg.InactiveURL.PlaceholderFile)
+		if err != nil {
+			log.Error("failed to parse inactive url placeholder", slogHelper.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	var geoDB *geoip.DB
+	if cfg.GeoIP.DatabaseFile != "" {
+		geoDB, err = geoip.Open(cfg.GeoIP.DatabaseFile)
+		if err != nil {
+			log.Error("failed to open geoip database", slogHelper.Err(err))
+			os.Exit(1)
+		}
+		defer geoDB.Close()
+	}
+
+	// init click pipeline
+	clickPipeline, err := workers.NewClickPipeline(
+		log,
+		clickRepo,
+		cfg.ClickPipeline.BufferSize,
+		cfg.ClickPipeline.Workers,
+		cfg.ClickPipeline.BatchSize,
+		cfg.ClickPipeline.FlushInterval,
+	)
+	if err != nil {
+		log.Error("failed to init click pipeline", slogHelper.Err(err))
+		os.Exit(1)
+	}
+	clickPipeline.Start()
 
 	// init services
-	urlService := services.NewUrlService(urlRepo)
-	refreshService := services.NewRefreshSessionService(refreshRepo, tM, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
-	userService := services.NewAuthService(userRepo, refreshService, h, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	urlService := services.NewUrlService(
+		urlRepo,
+		utmTemplateRepo,
+		aliasGenerator,
+		aliasPolicy,
+		cfg.Alias.MaxRetries,
+		h,
+		ratelimit.NewFailureLimiter(cfg.URLUnlock.MaxAttempts, cfg.URLUnlock.AttemptsWindow),
+		ratelimit.NewFailureLimiter(cfg.URLUnlock.MaxAliasAttempts, cfg.URLUnlock.AttemptsWindow),
+		unlockSecret,
+		cfg.URLUnlock.TTL,
+	)
+	utmTemplateService := services.NewUtmTemplateService(utmTemplateRepo)
+	clickService := services.NewClickService(clickRepo, clickPipeline, urlRepo, visitorSalt)
+	refreshService := services.NewRefreshSessionService(refreshRepo, securityEventRepo, tM, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	tokenRevocationService := services.NewTokenRevocationService(tokenRevocationRepo, revocation.NewStore(), tM, cfg.AccessTokenTTL)
+	userService := services.NewAuthService(userRepo, refreshService, tokenRevocationService, h, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.MaxRefreshSessions)
+
+	if err = tokenRevocationService.Sync(ctx); err != nil {
+		log.Error("failed to load token revocations", slogHelper.Err(err))
+		os.Exit(1)
+	}
+
+	// run background workers
+	urlSweeper := workers.NewURLSweeper(log, urlService, cfg.URLSweeper.Interval, cfg.URLSweeper.Retention)
+	go urlSweeper.Run(ctx)
+
+	if geoDB != nil {
+		geoIPReloader := workers.NewGeoIPReloader(log, geoDB, cfg.GeoIP.ReloadInterval)
+		go geoIPReloader.Run(ctx)
+	}
+
+	refreshSessionSweeper := workers.NewRefreshSessionSweeper(log, refreshService, cfg.SessionSweeper.Interval)
+	go refreshSessionSweeper.Run(ctx)
+
+	tokenRevocationSyncer := workers.NewTokenRevocationSyncer(log, tokenRevocationService, cfg.TokenRevocation.SyncInterval)
+	go tokenRevocationSyncer.Run(ctx)
+
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		log.Error("failed to parse trusted proxies", slogHelper.Err(err))
+		os.Exit(1)
+	}
 
 	// init router: chi, "chi render"
-	mux := v1.NewMux(log, urlService, userService, tM)
+	mux := v1.NewMux(log, urlService, clickService, userService, utmTemplateService, tM, tokenRevocationService, aliasPolicy, inactivePage, cfg.Redirect.CacheMaxAge, geoDB, trustedProxies)
+
+	allowedMethods, err := v1.RouteMethods(mux)
+	if err != nil {
+		log.Error("failed to get route methods", slogHelper.Err(err))
+		os.Exit(1)
+	}
 
 	c := cors.New(cors.Options{
-		AllowedMethods: []string{
-			http.MethodGet,
-			http.MethodPost,
-			http.MethodOptions,
-		},
+		AllowedMethods: allowedMethods,
 		AllowedOrigins: []string{
 			"http://localhost:3000"},
 		AllowCredentials: true,
@@ -89,8 +233,31 @@ func main() {
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Error("failed to start server")
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("failed to start server", slogHelper.Err(err))
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+
+	log.Info("stopping server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to stop server", slogHelper.Err(err))
+	}
+
+	// the pipeline gets its own timeout, the server may have used up the shared one
+	// and clicks of the last requests are buffered only after the server is stopped
+	pipelineCtx, pipelineCancel := context.WithTimeout(context.Background(), cfg.ClickPipeline.StopTimeout)
+	defer pipelineCancel()
+
+	if err := clickPipeline.Shutdown(pipelineCtx); err != nil {
+		log.Error("failed to flush clicks", slogHelper.Err(err))
 	}
 
 	log.Error("server stopped")
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type changePasswordInput struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72,containsany=!*&^?#@)(-+=$_"`
+}
+
+// ChangePassword sets the new password of the user. The user is logged out everywhere,
+// issued access tokens are revoked.
+func (h *AuthHandler) ChangePassword(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.user.ChangePassword"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		var req changePasswordInput
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		err = h.AuthService.ChangePassword(r.Context(), services.ChangePasswordInput{
+			UserId:      userId,
+			OldPassword: req.OldPassword,
+			NewPassword: req.NewPassword,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrWrongCred) || errors.Is(err, repository.ErrUserNotFound) {
+				log.Info("wrong password")
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.WrongCredentialsError())
+				return
+			}
+
+			log.Error("failed to change password", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		http.SetCookie(w, h.newRefreshCookie("", time.Unix(0, 0)))
+
+		log.Info("password changed")
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+type deleteAccountInput struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// DeleteAccount deletes the user. The password is asked again, so a stolen access token isn't enough.
+func (h *AuthHandler) DeleteAccount(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.user.DeleteAccount"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		var req deleteAccountInput
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		err = h.AuthService.DeleteAccount(r.Context(), services.DeleteAccountInput{
+			UserId:   userId,
+			Password: req.Password,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrWrongCred) || errors.Is(err, repository.ErrUserNotFound) {
+				log.Info("wrong password")
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.WrongCredentialsError())
+				return
+			}
+
+			log.Error("failed to delete account", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		http.SetCookie(w, h.newRefreshCookie("", time.Unix(0, 0)))
+
+		log.Info("account deleted")
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangePasswordHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldPassword string
+		newPassword string
+		respStatus  int
+		respError   string
+		mockError   error
+		noMock      bool
+	}{
+		{name: "success", oldPassword: "old_pass!", newPassword: "new_pass!", respStatus: http.StatusOK},
+		{name: "wrong password", oldPassword: "wrong", newPassword: "new_pass!", respStatus: http.StatusBadRequest, respError: response.WrongCredentialsErrorMessage, mockError: services.ErrWrongCred},
+		{name: "weak new password", oldPassword: "old_pass!", newPassword: "short", respStatus: http.StatusBadRequest, respError: "field NewPassword must be longer than 8 symbols", noMock: true},
+		{name: "unexpected error", oldPassword: "old_pass!", newPassword: "new_pass!", respStatus: http.StatusInternalServerError, respError: response.InternalErrorMessage, mockError: errors.New("unexpected error")},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			userService := mocks.NewUserService(t)
+
+			if !tc.noMock {
+				userService.On("ChangePassword", mock.Anything, services.ChangePasswordInput{
+					UserId:      1,
+					OldPassword: tc.oldPassword,
+					NewPassword: tc.newPassword,
+				}).Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Put("/api/v1/users/password", NewAuthHandler(userService, nil).ChangePassword(slogdiscard.NewDiscardLogger()))
+
+			body := `{"old_password": "` + tc.oldPassword + `", "new_password": "` + tc.newPassword + `"}`
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/users/password", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respStatus == http.StatusOK {
+				cookies := rr.Result().Cookies()
+				require.Len(t, cookies, 1)
+				require.Equal(t, refreshCookieName, cookies[0].Name)
+				require.Empty(t, cookies[0].Value)
+			}
+		})
+	}
+}
+
+func TestDeleteAccountHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		respStatus int
+		respError  string
+		mockError  error
+		noMock     bool
+	}{
+		{name: "success", password: "password!", respStatus: http.StatusOK},
+		{name: "wrong password", password: "wrong", respStatus: http.StatusBadRequest, respError: response.WrongCredentialsErrorMessage, mockError: services.ErrWrongCred},
+		{name: "empty password", password: "", respStatus: http.StatusBadRequest, respError: "field Password is a required field", noMock: true},
+		{name: "unexpected error", password: "password!", respStatus: http.StatusInternalServerError, respError: response.InternalErrorMessage, mockError: errors.New("unexpected error")},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			userService := mocks.NewUserService(t)
+
+			if !tc.noMock {
+				userService.On("DeleteAccount", mock.Anything, services.DeleteAccountInput{UserId: 1, Password: tc.password}).
+					Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Delete("/api/v1/users/me", NewAuthHandler(userService, nil).DeleteAccount(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me", strings.NewReader(`{"password": "`+tc.password+`"}`))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
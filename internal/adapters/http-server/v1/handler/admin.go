@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// BanUser bans the user with the id: the user is logged out everywhere, access tokens included,
+// and can't sign in anymore. It's for admins only.
+func (h *AuthHandler) BanUser(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.admin.BanUser"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		adminId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		userId, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("invalid user id", slog.String("id", chi.URLParam(r, "id")))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		if userId == adminId {
+			log.Info("admin tried to ban themselves", slog.Int("id", userId))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("you can't ban yourself"))
+			return
+		}
+
+		err = h.AuthService.BanUser(r.Context(), userId)
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				log.Info("user not found", slog.Int("id", userId))
+
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("user not found"))
+				return
+			}
+
+			log.Error("failed to ban user", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("user banned", slog.Int("id", userId), slog.Int("admin_id", adminId))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
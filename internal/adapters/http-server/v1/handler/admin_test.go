@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBanUserHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		respStatus int
+		respError  string
+		mockError  error
+		noMock     bool
+	}{
+		{name: "success", id: "7", respStatus: http.StatusOK},
+		{name: "not found", id: "7", respStatus: http.StatusNotFound, respError: "user not found", mockError: repository.ErrUserNotFound},
+		{name: "invalid id", id: "abc", respStatus: http.StatusBadRequest, respError: response.InvalidRequestErrorMessage, noMock: true},
+		{name: "yourself", id: "1", respStatus: http.StatusBadRequest, respError: "you can't ban yourself", noMock: true},
+		{name: "unexpected error", id: "7", respStatus: http.StatusInternalServerError, respError: response.InternalErrorMessage, mockError: errors.New("unexpected error")},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			userService := mocks.NewUserService(t)
+
+			if !tc.noMock {
+				userService.On("BanUser", mock.Anything, 7).Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Post("/api/v1/admin/users/{id}/ban", NewAuthHandler(userService, nil).BanUser(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+tc.id+"/ban", nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
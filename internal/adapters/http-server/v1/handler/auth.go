@@ -5,9 +5,11 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
 	"github.com/4aykovski/url_shortener/internal/services"
 	resp "github.com/4aykovski/url_shortener/pkg/api/response"
 	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
@@ -26,8 +28,14 @@ const (
 type AuthService interface {
 	SignUp(ctx context.Context, input services.AuthSignUpInput) error
 	SignIn(ctx context.Context, input services.AuthSignInInput) (*tokenManager.Tokens, error)
-	Logout(ctx context.Context, refreshToken string) error
-	Refresh(ctx context.Context, refreshToken string) (*tokenManager.Tokens, error)
+	Logout(ctx context.Context, input services.AuthLogoutInput) error
+	Refresh(ctx context.Context, input services.AuthRefreshInput) (*tokenManager.Tokens, error)
+	GetSessions(ctx context.Context, userId int) ([]entity.RefreshSession, error)
+	RevokeSession(ctx context.Context, input services.RevokeSessionInput) error
+	RevokeAllSessions(ctx context.Context, userId int) error
+	ChangePassword(ctx context.Context, input services.ChangePasswordInput) error
+	DeleteAccount(ctx context.Context, input services.DeleteAccountInput) error
+	BanUser(ctx context.Context, userId int) error
 }
 
 type AuthHandler struct {
@@ -153,6 +161,7 @@ func (h *AuthHandler) SignIn(log *slog.Logger) http.HandlerFunc {
 		tokens, err := h.AuthService.SignIn(r.Context(), services.AuthSignInInput{
 			Login:    inp.Login,
 			Password: inp.Password,
+			Client:   sessionClient(r),
 		})
 		if err != nil {
 			if errors.Is(err, repository.ErrUserNotFound) || errors.Is(err, services.ErrWrongCred) {
@@ -162,6 +171,13 @@ func (h *AuthHandler) SignIn(log *slog.Logger) http.HandlerFunc {
 				render.JSON(w, r, resp.WrongCredentialsError())
 				return
 			}
+			if errors.Is(err, services.ErrUserBanned) {
+				log.Info("banned user tried to sign in", slog.String("login", inp.Login))
+
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("account is banned"))
+				return
+			}
 
 			log.Error("can't sign in", slog.String("login", inp.Login), slogHelper.Err(err))
 
@@ -197,12 +213,16 @@ func (h *AuthHandler) Logout(log *slog.Logger) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
+		// the access token is optional, it's revoked with the session if it's given
+		accessToken := bearerToken(r)
+
 		var token string
 		cookie, err := r.Cookie(refreshCookieName)
 		if err != nil {
 			var res authLogoutInput
 			err = render.DecodeJSON(r.Body, &res)
-			if err != nil || res.RefreshToken == "" {
+			// the access token is still revoked without the refresh one
+			if (err != nil || res.RefreshToken == "") && accessToken == "" {
 				log.Info("refreshCookie is not specified")
 
 				render.Status(r, http.StatusBadRequest)
@@ -215,7 +235,15 @@ func (h *AuthHandler) Logout(log *slog.Logger) http.HandlerFunc {
 			token = cookie.Value
 		}
 
-		err = h.AuthService.Logout(r.Context(), token)
+		err = h.AuthService.Logout(r.Context(), services.AuthLogoutInput{
+			RefreshToken: token,
+			AccessToken:  accessToken,
+		})
+		// the access token is revoked before the session is looked up, so logout succeeded if it's given
+		if errors.Is(err, repository.ErrRefreshSessionNotFound) && accessToken != "" {
+			log.Info("can't find session, access token is revoked")
+			err = nil
+		}
 		if err != nil {
 			if errors.Is(err, repository.ErrRefreshSessionNotFound) {
 				log.Info("can't find session")
@@ -274,7 +302,10 @@ func (h *AuthHandler) Refresh(log *slog.Logger) http.HandlerFunc {
 		emptyCookie := h.newRefreshCookie("", time.Now().Add(-100*time.Second))
 		http.SetCookie(w, emptyCookie)
 
-		tokens, err := h.AuthService.Refresh(r.Context(), token)
+		tokens, err := h.AuthService.Refresh(r.Context(), services.AuthRefreshInput{
+			RefreshToken: token,
+			Client:       sessionClient(r),
+		})
 		if err != nil {
 			if errors.Is(err, repository.ErrRefreshSessionNotFound) {
 				log.Info("can't find session")
@@ -283,6 +314,20 @@ func (h *AuthHandler) Refresh(log *slog.Logger) http.HandlerFunc {
 				render.JSON(w, r, resp.WrongCredentialsError())
 				return
 			}
+			if errors.Is(err, services.ErrRefreshTokenReused) {
+				log.Warn("refresh token reused, session family revoked")
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.WrongCredentialsError())
+				return
+			}
+			if errors.Is(err, services.ErrTokenExpired) {
+				log.Info("refresh token expired")
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.WrongCredentialsError())
+				return
+			}
 
 			log.Error("can't refresh tokens", slogHelper.Err(err))
 
@@ -305,6 +350,16 @@ func (h *AuthHandler) Refresh(log *slog.Logger) http.HandlerFunc {
 	}
 }
 
+// bearerToken returns the access token from Authorization header or empty string if there is none.
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	return token
+}
+
 func (h *AuthHandler) newRefreshCookie(refreshToken string, time time.Time) *http.Cookie {
 	return &http.Cookie{
 		Name:     refreshCookieName,
@@ -196,6 +196,17 @@ func TestSignInHandler(t *testing.T) {
 			respError: response.WrongCredentialsErrorMessage,
 			mockError: repository.ErrUserNotFound,
 		},
+		{
+			name: "banned user",
+			input: authSignInInput{
+				Login:    "ssff23",
+				Password: "qwerty123!4",
+			},
+			tokens:    tokenManager.Tokens{},
+			status:    response.StatusError,
+			respError: "account is banned",
+			mockError: fmt.Errorf("services.user.SignIn: %w", services.ErrUserBanned),
+		},
 		{
 			name: "unexpected error",
 			input: authSignInInput{
@@ -251,7 +262,11 @@ func TestSignInHandler(t *testing.T) {
 			inp := services.AuthSignInInput{Login: tc.input.Login, Password: tc.input.Password}
 
 			if tc.respError == "" || tc.mockError != nil {
-				userService.On("SignIn", mock.Anything, inp).
+				// the request is sent by http.Client from the loopback
+				signInInput := inp
+				signInInput.Client = services.SessionClient{UserAgent: "Go-http-client/1.1", IP: "127.0.0.1"}
+
+				userService.On("SignIn", mock.Anything, signInInput).
 					Return(&tc.tokens, tc.mockError).Once()
 			}
 
@@ -279,3 +294,141 @@ func TestSignInHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     tokenManager.Tokens
+		respStatus int
+		respError  string
+		mockError  error
+	}{
+		{
+			name: "success",
+			tokens: tokenManager.Tokens{
+				AccessToken:  "random string",
+				RefreshToken: "random string",
+			},
+			respStatus: http.StatusOK,
+		},
+		{
+			name:       "session not found",
+			respStatus: http.StatusBadRequest,
+			respError:  response.WrongCredentialsErrorMessage,
+			mockError:  repository.ErrRefreshSessionNotFound,
+		},
+		{
+			name:       "reused token",
+			respStatus: http.StatusBadRequest,
+			respError:  response.WrongCredentialsErrorMessage,
+			mockError:  fmt.Errorf("services.refresh_session.revokeReusedFamily: %w", services.ErrRefreshTokenReused),
+		},
+		{
+			name:       "unexpected error",
+			respStatus: http.StatusInternalServerError,
+			respError:  response.InternalErrorMessage,
+			mockError:  errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			userService := mocks.NewUserService(t)
+
+			refreshInput := services.AuthRefreshInput{
+				RefreshToken: "refresh token",
+				Client:       services.SessionClient{UserAgent: "test", IP: "192.0.2.1"},
+			}
+			userService.On("Refresh", mock.Anything, refreshInput).
+				Return(&tc.tokens, tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/users/auth/refresh", NewAuthHandler(userService, nil).Refresh(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users/auth/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: refreshCookieName, Value: "refresh token"})
+			req.Header.Set("User-Agent", "test")
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp tokenResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.tokens.RefreshToken, resp.RefreshToken)
+		})
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken string
+		authHeader   string
+		accessToken  string
+		respStatus   int
+		respError    string
+		mockError    error
+		noMock       bool
+	}{
+		{name: "success", refreshToken: "refresh token", respStatus: http.StatusOK},
+		{name: "with access token", refreshToken: "refresh token", authHeader: "Bearer access token", accessToken: "access token", respStatus: http.StatusOK},
+		{name: "only access token", authHeader: "Bearer access token", accessToken: "access token", respStatus: http.StatusOK},
+		{name: "no tokens", respStatus: http.StatusBadRequest, respError: response.WrongCredentialsErrorMessage, noMock: true},
+		{name: "invalid auth header", refreshToken: "refresh token", authHeader: "Basic credentials", respStatus: http.StatusOK},
+		{name: "session not found", refreshToken: "refresh token", respStatus: http.StatusBadRequest, respError: response.WrongCredentialsErrorMessage, mockError: repository.ErrRefreshSessionNotFound},
+		{name: "session not found with access token", refreshToken: "refresh token", authHeader: "Bearer access token", accessToken: "access token", respStatus: http.StatusOK, mockError: repository.ErrRefreshSessionNotFound},
+		{name: "unexpected error", refreshToken: "refresh token", respStatus: http.StatusInternalServerError, respError: response.InternalErrorMessage, mockError: errors.New("unexpected error")},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			userService := mocks.NewUserService(t)
+			if !tc.noMock {
+				userService.On("Logout", mock.Anything, services.AuthLogoutInput{
+					RefreshToken: tc.refreshToken,
+					AccessToken:  tc.accessToken,
+				}).Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/users/auth/logout", NewAuthHandler(userService, nil).Logout(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users/auth/logout", nil)
+			if tc.refreshToken != "" {
+				req.AddCookie(&http.Cookie{Name: refreshCookieName, Value: tc.refreshToken})
+			}
+			if tc.authHeader != "" {
+				req.Header.Set("Authorization", tc.authHeader)
+			}
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respStatus == http.StatusOK {
+				cookies := rr.Result().Cookies()
+				require.Len(t, cookies, 1)
+				require.Equal(t, refreshCookieName, cookies[0].Name)
+				require.Empty(t, cookies[0].Value)
+			}
+		})
+	}
+}
@@ -2,9 +2,15 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"net"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/middleware"
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
 func getUserId(ctx context.Context) (int, bool) {
@@ -14,3 +20,44 @@ func getUserId(ctx context.Context) (int, bool) {
 	}
 	return userID, true
 }
+
+// clientIP returns ip of the client without port. RealIP middleware is expected to normalize RemoteAddr.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// aliasPath returns the path of the short url without the rest path of prefix urls.
+func aliasPath(r *http.Request) string {
+	return strings.TrimSuffix(r.URL.Path, "/"+restPath(r))
+}
+
+// restPath returns the path after alias matched by wildcard route of prefix urls.
+// URLFormat middleware cuts extension off the route path, so it's added back.
+func restPath(r *http.Request) string {
+	path := chi.URLParam(r, "*")
+	if format, _ := r.Context().Value(chiMiddleware.URLFormatCtxKey).(string); path != "" && format != "" {
+		path += "." + format
+	}
+	return path
+}
+
+// nullable is a json field that distinguishes absent value from explicit null.
+// Set is true if the field is present in json, Value is nil if the field is null.
+type nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	return json.Unmarshal(data, &n.Value)
+}
+
+// isNull reports whether the field is explicitly set to null.
+func (n nullable[T]) isNull() bool {
+	return n.Set && n.Value == nil
+}
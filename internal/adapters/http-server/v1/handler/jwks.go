@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// jwksMaxAge is how long clients may cache the key set. New keys should be scheduled at least that long
+// before they start signing.
+const jwksMaxAge = "max-age=300"
+
+// JWKS returns public keys verifying access tokens, so other services can verify them without the secret.
+func (h *AuthHandler) JWKS(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.user.JWKS"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		jwks := h.tokenManager.JWKS()
+
+		log.Debug("jwks fetched", slog.Int("keys", len(jwks.Keys)))
+
+		w.Header().Set("Cache-Control", "public, "+jwksMaxAge)
+		render.JSON(w, r, jwks)
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	tokenManager "github.com/4aykovski/url_shortener/pkg/manager/token"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys, err := tokenManager.NewKeySet([]*tokenManager.Key{
+		tokenManager.NewKey("key-1", tokenManager.AlgorithmEdDSA, private, time.Now().Add(-time.Hour), time.Time{}),
+	}, time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		manager *tokenManager.Manager
+		kids    []string
+	}{
+		{name: "keys", manager: tokenManager.NewManagerWithKeys("secret", keys, time.Time{}), kids: []string{"key-1"}},
+		{name: "secret", manager: tokenManager.NewManager("secret"), kids: []string{}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := chi.NewRouter()
+			r.Get("/.well-known/jwks.json", NewAuthHandler(nil, tc.manager).JWKS(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Contains(t, rr.Header().Get("Cache-Control"), "max-age")
+
+			var jwks tokenManager.JWKS
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &jwks))
+
+			kids := []string{}
+			for _, key := range jwks.Keys {
+				kids = append(kids, key.KeyID)
+			}
+			require.Equal(t, tc.kids, kids)
+		})
+	}
+}
@@ -5,6 +5,8 @@ package mocks
 import (
 	context "context"
 
+	entity "github.com/4aykovski/url_shortener/internal/entity"
+
 	mock "github.com/stretchr/testify/mock"
 
 	services "github.com/4aykovski/url_shortener/internal/services"
@@ -17,13 +19,13 @@ type UserService struct {
 	mock.Mock
 }
 
-// Logout provides a mock function with given fields: ctx, refreshToken
-func (_m *UserService) Logout(ctx context.Context, refreshToken string) error {
-	ret := _m.Called(ctx, refreshToken)
+// BanUser provides a mock function with given fields: ctx, userId
+func (_m *UserService) BanUser(ctx context.Context, userId int) error {
+	ret := _m.Called(ctx, userId)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, refreshToken)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userId)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -31,25 +33,93 @@ func (_m *UserService) Logout(ctx context.Context, refreshToken string) error {
 	return r0
 }
 
-// Refresh provides a mock function with given fields: ctx, refreshToken
-func (_m *UserService) Refresh(ctx context.Context, refreshToken string) (*token_manager.Tokens, error) {
-	ret := _m.Called(ctx, refreshToken)
+// ChangePassword provides a mock function with given fields: ctx, input
+func (_m *UserService) ChangePassword(ctx context.Context, input services.ChangePasswordInput) error {
+	ret := _m.Called(ctx, input)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, services.ChangePasswordInput) error); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAccount provides a mock function with given fields: ctx, input
+func (_m *UserService) DeleteAccount(ctx context.Context, input services.DeleteAccountInput) error {
+	ret := _m.Called(ctx, input)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, services.DeleteAccountInput) error); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetSessions provides a mock function with given fields: ctx, userId
+func (_m *UserService) GetSessions(ctx context.Context, userId int) ([]entity.RefreshSession, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 []entity.RefreshSession
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]entity.RefreshSession, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []entity.RefreshSession); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.RefreshSession)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, input
+func (_m *UserService) Logout(ctx context.Context, input services.AuthLogoutInput) error {
+	ret := _m.Called(ctx, input)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, services.AuthLogoutInput) error); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, input
+func (_m *UserService) Refresh(ctx context.Context, input services.AuthRefreshInput) (*token_manager.Tokens, error) {
+	ret := _m.Called(ctx, input)
 
 	var r0 *token_manager.Tokens
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*token_manager.Tokens, error)); ok {
-		return rf(ctx, refreshToken)
+	if rf, ok := ret.Get(0).(func(context.Context, services.AuthRefreshInput) (*token_manager.Tokens, error)); ok {
+		return rf(ctx, input)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *token_manager.Tokens); ok {
-		r0 = rf(ctx, refreshToken)
+	if rf, ok := ret.Get(0).(func(context.Context, services.AuthRefreshInput) *token_manager.Tokens); ok {
+		r0 = rf(ctx, input)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*token_manager.Tokens)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, refreshToken)
+	if rf, ok := ret.Get(1).(func(context.Context, services.AuthRefreshInput) error); ok {
+		r1 = rf(ctx, input)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -57,6 +127,34 @@ func (_m *UserService) Refresh(ctx context.Context, refreshToken string) (*token
 	return r0, r1
 }
 
+// RevokeAllSessions provides a mock function with given fields: ctx, userId
+func (_m *UserService) RevokeAllSessions(ctx context.Context, userId int) error {
+	ret := _m.Called(ctx, userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: ctx, input
+func (_m *UserService) RevokeSession(ctx context.Context, input services.RevokeSessionInput) error {
+	ret := _m.Called(ctx, input)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, services.RevokeSessionInput) error); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SignIn provides a mock function with given fields: ctx, input
 func (_m *UserService) SignIn(ctx context.Context, input services.AuthSignInInput) (*token_manager.Tokens, error) {
 	ret := _m.Called(ctx, input)
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/4aykovski/url_shortener/pkg/useragent"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// sessionClient returns the client of the request that gets session tokens.
+func sessionClient(r *http.Request) services.SessionClient {
+	return services.SessionClient{
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r),
+	}
+}
+
+type sessionResponse struct {
+	Id         int       `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Device     string    `json:"device"`
+	OS         string    `json:"os"`
+	Browser    string    `json:"browser"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func newSessionResponse(session *entity.RefreshSession) sessionResponse {
+	info := useragent.Parse(session.UserAgent)
+
+	return sessionResponse{
+		Id:         session.Id,
+		UserAgent:  session.UserAgent,
+		Device:     info.Device,
+		OS:         info.OS,
+		Browser:    info.Browser,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+	}
+}
+
+type sessionsResponse struct {
+	resp.Response
+	Sessions []sessionResponse `json:"sessions"`
+}
+
+// Sessions returns active sessions of the user. Session id changes on every refresh of its tokens.
+func (h *AuthHandler) Sessions(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.user.Sessions"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		sessions, err := h.AuthService.GetSessions(r.Context(), userId)
+		if err != nil {
+			log.Error("failed to get sessions", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		res := make([]sessionResponse, 0, len(sessions))
+		for i := range sessions {
+			res = append(res, newSessionResponse(&sessions[i]))
+		}
+
+		log.Info("sessions fetched")
+
+		render.JSON(w, r, sessionsResponse{
+			Response: resp.OK(),
+			Sessions: res,
+		})
+	}
+}
+
+// RevokeSession logs the user out of the session with the id.
+func (h *AuthHandler) RevokeSession(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.user.RevokeSession"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		sessionId, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("invalid session id", slog.String("id", chi.URLParam(r, "id")))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		err = h.AuthService.RevokeSession(r.Context(), services.RevokeSessionInput{
+			UserId:    userId,
+			SessionId: sessionId,
+		})
+		if err != nil {
+			if errors.Is(err, repository.ErrRefreshSessionNotFound) {
+				log.Info("session not found", slog.Int("id", sessionId))
+
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("session not found"))
+				return
+			}
+
+			log.Error("failed to revoke session", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("session revoked", slog.Int("id", sessionId))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// RevokeAllSessions logs the user out everywhere, including the current client.
+func (h *AuthHandler) RevokeAllSessions(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.user.RevokeAllSessions"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		err := h.AuthService.RevokeAllSessions(r.Context(), userId)
+		if err != nil {
+			log.Error("failed to revoke sessions", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		http.SetCookie(w, h.newRefreshCookie("", time.Unix(0, 0)))
+
+		log.Info("all sessions revoked")
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/4aykovski/url_shortener/pkg/useragent"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionsHandler(t *testing.T) {
+	const firefox = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0"
+
+	createdAt := time.Date(2024, 7, 22, 9, 0, 0, 0, time.UTC)
+
+	userService := mocks.NewUserService(t)
+	userService.On("GetSessions", mock.Anything, 1).
+		Return([]entity.RefreshSession{{
+			Id:         7,
+			UserId:     1,
+			UserAgent:  firefox,
+			IP:         "192.0.2.1",
+			CreatedAt:  createdAt,
+			LastUsedAt: createdAt.Add(time.Hour),
+		}}, nil).Once()
+
+	r := chi.NewRouter()
+	r.Use(withUserId("1"))
+	r.Get("/api/v1/users/sessions", NewAuthHandler(userService, nil).Sessions(slogdiscard.NewDiscardLogger()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/sessions", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp sessionsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Equal(t, []sessionResponse{{
+		Id:         7,
+		UserAgent:  firefox,
+		Device:     useragent.DeviceDesktop,
+		OS:         useragent.OSWindows,
+		Browser:    useragent.BrowserFirefox,
+		IP:         "192.0.2.1",
+		CreatedAt:  createdAt,
+		LastUsedAt: createdAt.Add(time.Hour),
+	}}, resp.Sessions)
+}
+
+func TestRevokeSessionHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		respStatus int
+		respError  string
+		mockError  error
+		noMock     bool
+	}{
+		{name: "success", id: "7", respStatus: http.StatusOK},
+		{name: "not found", id: "7", respStatus: http.StatusNotFound, respError: "session not found", mockError: repository.ErrRefreshSessionNotFound},
+		{name: "invalid id", id: "abc", respStatus: http.StatusBadRequest, respError: response.InvalidRequestErrorMessage, noMock: true},
+		{name: "unexpected error", id: "7", respStatus: http.StatusInternalServerError, respError: response.InternalErrorMessage, mockError: errors.New("unexpected error")},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			userService := mocks.NewUserService(t)
+
+			if !tc.noMock {
+				userService.On("RevokeSession", mock.Anything, services.RevokeSessionInput{UserId: 1, SessionId: 7}).
+					Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Delete("/api/v1/users/sessions/{id}", NewAuthHandler(userService, nil).RevokeSession(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/sessions/"+tc.id, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestRevokeAllSessionsHandler(t *testing.T) {
+	userService := mocks.NewUserService(t)
+	userService.On("RevokeAllSessions", mock.Anything, 1).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Use(withUserId("1"))
+	r.Delete("/api/v1/users/sessions", NewAuthHandler(userService, nil).RevokeAllSessions(slogdiscard.NewDiscardLogger()))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/sessions", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, refreshCookieName, cookies[0].Name)
+	require.Empty(t, cookies[0].Value)
+}
@@ -3,11 +3,18 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/4aykovski/url_shortener/internal/entity"
 	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
 	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/geoip"
 	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -15,28 +22,94 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name urlService --exported
 type urlService interface {
 	SaveURL(ctx context.Context, input services.SaveURLInput) (string, error)
-	GetURL(ctx context.Context, input services.GetURLInput) (string, error)
+	SaveURLs(ctx context.Context, input services.SaveURLsInput) ([]services.SaveURLResult, error)
+	GetURL(ctx context.Context, input services.GetURLInput) (*entity.Url, error)
+	PreviewURL(ctx context.Context, input services.GetURLInput) (*entity.Url, error)
 	GetAllUserUrls(ctx context.Context, input services.GetAllUserUrlsInput) (services.GetAllUserUrlsOutput, error)
 	DeleteURL(ctx context.Context, input services.DeleteURLInput) error
+	UpdateURL(ctx context.Context, input services.UpdateURLInput) error
+	GetURLHistory(ctx context.Context, input services.GetURLHistoryInput) ([]entity.UrlVersion, error)
+	RollbackURL(ctx context.Context, input services.RollbackURLInput) error
+	ImportURLs(ctx context.Context, urls []services.SaveURLInput) []services.SaveURLResult
+	ExportUserURLs(ctx context.Context, input services.ExportUserURLsInput, fn func(url *entity.Url) error) error
+	UnlockURL(ctx context.Context, input services.UnlockURLInput) (services.UnlockURLOutput, error)
+	GetTargetingRules(ctx context.Context, input services.GetTargetingRulesInput) (*entity.Url, error)
+	SetTargetingRules(ctx context.Context, input services.SetTargetingRulesInput) error
+	SetURLVariants(ctx context.Context, input services.SetURLVariantsInput) error
+}
+
+type geoLocator interface {
+	Lookup(ip net.IP) (geoip.Location, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name clickService --exported
+type clickService interface {
+	RecordClick(ctx context.Context, input services.RecordClickInput) error
+	GetURLStats(ctx context.Context, input services.GetURLStatsInput) (*entity.UrlStats, error)
 }
 
 type UrlHandler struct {
-	urlService urlService
+	urlService   urlService
+	clickService clickService
+	validate     *validator.Validate
+	inactive     InactivePage
+	// redirectMaxAge is how long permanent redirects may be cached, they aren't cached if it's zero
+	redirectMaxAge time.Duration
+	geo            geoLocator
 }
 
+// NewUrlHandler creates url handler. Geo can be nil, then geo targeting rules never match.
 func NewUrlHandler(
 	urlService urlService,
+	clickService clickService,
+	aliasPolicy *aliaspolicy.Policy,
+	inactive InactivePage,
+	redirectMaxAge time.Duration,
+	geo geoLocator,
 ) *UrlHandler {
 	return &UrlHandler{
-		urlService: urlService,
+		urlService:     urlService,
+		clickService:   clickService,
+		validate:       newValidator(aliasPolicy),
+		inactive:       inactive,
+		redirectMaxAge: redirectMaxAge,
+		geo:            geo,
 	}
 }
 
 type UrlSaveInput struct {
-	URL   string `json:"url" validate:"required,url"`
-	Alias string `json:"alias,omitempty"`
+	URL       string     `json:"url" validate:"required_without=Variants,omitempty,url"`
+	Alias     string     `json:"alias,omitempty" validate:"omitempty,alias_length,alias_charset,alias_reserved"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// TTL is a link lifetime in seconds
+	TTL       int64 `json:"ttl,omitempty" validate:"omitempty,gt=0"`
+	MaxClicks *int  `json:"max_clicks,omitempty" validate:"omitempty,gt=0"`
+	// Password protects the link, bcrypt uses only first 72 bytes of it
+	Password string `json:"password,omitempty" validate:"omitempty,max=72"`
+	// ActiveFrom and ActiveUntil limit the time the link works, FallbackURL is used outside of this window
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	FallbackURL string     `json:"fallback_url,omitempty" validate:"omitempty,url"`
+	// RedirectCode is http status used to redirect, 302 by default
+	RedirectCode int `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	// ForwardQuery merges query of the short link into the destination, QueryConflict decides
+	// what to do with params already in the destination: keep (default), override or append
+	ForwardQuery  bool   `json:"forward_query,omitempty"`
+	QueryConflict string `json:"query_conflict,omitempty" validate:"omitempty,oneof=keep override append"`
+	// Prefix appends the rest of the short link path to the destination path
+	Prefix bool `json:"prefix,omitempty"`
+	// UTM params are added to the destination, missing ones are taken from the user's UTMTemplate if it's set
+	UTM         *UtmInput `json:"utm,omitempty"`
+	UTMTemplate string    `json:"utm_template,omitempty" validate:"omitempty,max=64"`
+	// Variants split visitors between several destinations, url defaults to the first of them
+	Variants []UrlVariantInput `json:"variants,omitempty" validate:"omitempty,min=2,max=10,dive"`
+	// Title and Description are shown on the preview page, Interstitial shows it before every redirect
+	Title        string `json:"title,omitempty" validate:"omitempty,max=200"`
+	Description  string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Interstitial bool   `json:"interstitial,omitempty"`
 }
 
 type aliasResponse struct {
@@ -74,7 +147,7 @@ func (h *UrlHandler) Save(log *slog.Logger) http.HandlerFunc {
 
 		log.Info("request body decoded", slog.Any("request", req))
 
-		if err = validator.New().Struct(req); err != nil {
+		if err = h.validate.Struct(req); err != nil {
 			var validateErr validator.ValidationErrors
 			errors.As(err, &validateErr)
 
@@ -86,9 +159,29 @@ func (h *UrlHandler) Save(log *slog.Logger) http.HandlerFunc {
 		}
 
 		alias, err := h.urlService.SaveURL(r.Context(), services.SaveURLInput{
-			URL:    req.URL,
-			Alias:  req.Alias,
-			UserId: userId,
+			URL:          req.URL,
+			Alias:        req.Alias,
+			UserId:       userId,
+			ExpiresAt:    req.ExpiresAt,
+			TTL:          time.Duration(req.TTL) * time.Second,
+			MaxClicks:    req.MaxClicks,
+			Password:     req.Password,
+			ActiveFrom:   req.ActiveFrom,
+			ActiveUntil:  req.ActiveUntil,
+			FallbackURL:  req.FallbackURL,
+			RedirectCode: req.RedirectCode,
+
+			ForwardQuery:  req.ForwardQuery,
+			QueryConflict: req.QueryConflict,
+			Prefix:        req.Prefix,
+
+			UTM:         req.UTM.params(),
+			UTMTemplate: req.UTMTemplate,
+			Variants:    variantsInput(req.Variants),
+
+			Title:        req.Title,
+			Description:  req.Description,
+			Interstitial: req.Interstitial,
 		})
 		if err != nil {
 			if errors.Is(err, services.ErrAliasAlreadyExists) {
@@ -98,6 +191,34 @@ func (h *UrlHandler) Save(log *slog.Logger) http.HandlerFunc {
 				render.JSON(w, r, resp.Error("alias already exists"))
 				return
 			}
+			if errors.Is(err, services.ErrInvalidExpiration) {
+				log.Info("invalid expiration", slogHelper.Err(err))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid expiration"))
+				return
+			}
+			if errors.Is(err, services.ErrInvalidActivationWindow) {
+				log.Info("invalid activation window", slogHelper.Err(err))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid activation window"))
+				return
+			}
+			if errors.Is(err, services.ErrUtmTemplateNotFound) {
+				log.Info("utm template not found", slog.String("utm_template", req.UTMTemplate))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("utm template not found"))
+				return
+			}
+			if errors.Is(err, services.ErrInvalidURLVariants) {
+				log.Info("invalid url variants", slogHelper.Err(err))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid url variants"))
+				return
+			}
 			log.Error("failed to save url", slogHelper.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
@@ -111,11 +232,37 @@ func (h *UrlHandler) Save(log *slog.Logger) http.HandlerFunc {
 	}
 }
 
+type urlResponse struct {
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxClicks *int       `json:"max_clicks,omitempty"`
+	Clicks    int        `json:"clicks"`
+	Protected bool       `json:"protected"`
+
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	FallbackURL string     `json:"fallback_url,omitempty"`
+
+	RedirectCode  int    `json:"redirect_code"`
+	ForwardQuery  bool   `json:"forward_query"`
+	QueryConflict string `json:"query_conflict"`
+	Prefix        bool   `json:"prefix"`
+
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Interstitial bool   `json:"interstitial"`
+}
+
 type GetAllUserUrlsResponse struct {
 	resp.Response
-	Urls map[string]string `json:"urls"`
+	Urls       []urlResponse `json:"urls"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
+// GetAllUserUrls returns a page of user urls. Supported query params:
+// limit, cursor, sort (created_at, clicks), order (desc, asc) and q to search by alias or destination.
 func (h *UrlHandler) GetAllUserUrls(log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "v1.handler.url.GetAllUserUrls"
@@ -133,17 +280,35 @@ func (h *UrlHandler) GetAllUserUrls(log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		query := r.URL.Query()
+
+		var limit int
+		if rawLimit := query.Get("limit"); rawLimit != "" {
+			var err error
+			limit, err = strconv.Atoi(rawLimit)
+			if err != nil {
+				log.Info("invalid limit", slog.String("limit", rawLimit))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.InvalidRequestError())
+				return
+			}
+		}
+
 		output, err := h.urlService.GetAllUserUrls(r.Context(), services.GetAllUserUrlsInput{
 			UserId: userId,
+			Search: query.Get("q"),
+			SortBy: query.Get("sort"),
+			Order:  query.Get("order"),
+			Limit:  limit,
+			Cursor: query.Get("cursor"),
 		})
 		if err != nil {
-			if errors.Is(err, services.ErrUserHasNoUrls) {
-				log.Info("user has no urls")
+			if errors.Is(err, services.ErrInvalidListParams) {
+				log.Info("invalid list params", slogHelper.Err(err))
 
-				render.Status(r, http.StatusOK)
-				render.JSON(w, r, GetAllUserUrlsResponse{
-					Response: resp.OK(),
-				})
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid list params"))
 				return
 			}
 
@@ -154,15 +319,41 @@ func (h *UrlHandler) GetAllUserUrls(log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		urls := make([]urlResponse, 0, len(output.Urls))
+		for _, url := range output.Urls {
+			urls = append(urls, urlResponse{
+				Alias:     url.Alias,
+				URL:       url.Url,
+				CreatedAt: url.CreatedAt,
+				ExpiresAt: url.ExpiresAt,
+				MaxClicks: url.MaxClicks,
+				Clicks:    url.ClickCount,
+				Protected: url.IsProtected(),
+
+				ActiveFrom:  url.ActiveFrom,
+				ActiveUntil: url.ActiveUntil,
+				FallbackURL: url.FallbackUrl,
+
+				RedirectCode:  url.RedirectCode,
+				ForwardQuery:  url.ForwardQuery,
+				QueryConflict: url.QueryConflict,
+				Prefix:        url.Prefix,
+
+				Title:        url.Title,
+				Description:  url.Description,
+				Interstitial: url.Interstitial,
+			})
+		}
+
 		log.Info("urls fetched")
 
 		render.Status(r, http.StatusOK)
 		render.JSON(w, r, GetAllUserUrlsResponse{
-			Response: resp.OK(),
-			Urls:     output.Urls,
+			Response:   resp.OK(),
+			Urls:       urls,
+			NextCursor: output.NextCursor,
 		})
 	}
-
 }
 
 func (h *UrlHandler) Redirect(log *slog.Logger) http.HandlerFunc {
@@ -174,6 +365,175 @@ func (h *UrlHandler) Redirect(log *slog.Logger) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
+		alias, preview := previewAlias(r)
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		path := restPath(r)
+		if !entity.ValidDestinationPath(path) {
+			log.Info("invalid path", slog.String("path", path))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid path"))
+			return
+		}
+
+		var unlockToken string
+		if cookie, err := r.Cookie(unlockCookieName); err == nil {
+			unlockToken = cookie.Value
+		}
+
+		input := services.GetURLInput{
+			Alias:       alias,
+			UnlockToken: unlockToken,
+			Path:        path,
+		}
+
+		if preview {
+			h.preview(w, r, input, log)
+			return
+		}
+
+		query := r.URL.Query()
+		input.SkipInterstitial = query.Get(confirmParam) == "1"
+		query.Del(confirmParam)
+
+		url, err := h.urlService.GetURL(r.Context(), input)
+		if err != nil {
+			var interstitialErr *services.InterstitialError
+			if errors.As(err, &interstitialErr) {
+				log.Info("url shows interstitial page", "alias", alias)
+
+				renderPreview(w, r, interstitialErr.URL, path, query, log)
+				return
+			}
+
+			h.renderGetURLError(w, r, alias, err, log)
+			return
+		}
+
+		log.Info("got url", slog.String("url", url.Url))
+
+		base, variantId := h.destinationBase(w, r, url, log)
+
+		destination, err := url.DestinationFrom(base, path, query)
+		if err != nil {
+			log.Error("failed to build destination", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		err = h.clickService.RecordClick(r.Context(), services.RecordClickInput{
+			UrlId:     url.Id,
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+			IP:        clientIP(r),
+			RequestId: middleware.GetReqID(r.Context()),
+			VariantId: variantId,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrClickDropped) {
+				log.Debug("click dropped", slogHelper.Err(err))
+			} else {
+				log.Error("failed to record click", slogHelper.Err(err))
+			}
+		}
+
+		w.Header().Set("Cache-Control", redirectCacheControl(url, h.redirectMaxAge))
+		http.Redirect(w, r, destination, url.RedirectCode)
+	}
+}
+
+// renderGetURLError responds to the error of getting url to redirect to or to preview.
+func (h *UrlHandler) renderGetURLError(w http.ResponseWriter, r *http.Request, alias string, err error, log *slog.Logger) {
+	if errors.Is(err, services.ErrURLNotFound) {
+		log.Info("url not found", "alias", alias)
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, resp.Error("url not found"))
+		return
+	}
+	if errors.Is(err, services.ErrURLExpired) {
+		log.Info("url expired", "alias", alias)
+
+		render.Status(r, http.StatusGone)
+		render.JSON(w, r, resp.Error("url expired"))
+		return
+	}
+	if errors.Is(err, services.ErrURLClicksExhausted) {
+		log.Info("url clicks limit exhausted", "alias", alias)
+
+		render.Status(r, http.StatusGone)
+		render.JSON(w, r, resp.Error("url clicks limit exhausted"))
+		return
+	}
+	var inactiveErr *services.InactiveURLError
+	if errors.As(err, &inactiveErr) {
+		log.Info("url is inactive", "alias", alias, slogHelper.Err(err))
+
+		h.inactive.serve(w, r, alias, inactiveErr)
+		return
+	}
+	if errors.Is(err, services.ErrURLPasswordRequired) {
+		log.Info("url password required", "alias", alias)
+
+		renderUnlockForm(w, http.StatusUnauthorized, alias, "")
+		return
+	}
+
+	log.Error("failed to get url", slogHelper.Err(err))
+
+	render.Status(r, http.StatusInternalServerError)
+	render.JSON(w, r, resp.InternalError())
+}
+
+// UrlUpdateInput contains attributes of the url to change. Absent fields are left unchanged,
+// expires_at and max_clicks set to null are removed.
+type UrlUpdateInput struct {
+	URL       *string             `json:"url,omitempty" validate:"omitempty,url"`
+	ExpiresAt nullable[time.Time] `json:"expires_at"`
+	// TTL is a new link lifetime in seconds counting from now
+	TTL       int64         `json:"ttl,omitempty" validate:"omitempty,gt=0"`
+	MaxClicks nullable[int] `json:"max_clicks"`
+
+	ActiveFrom  nullable[time.Time] `json:"active_from"`
+	ActiveUntil nullable[time.Time] `json:"active_until"`
+	FallbackURL nullable[string]    `json:"fallback_url"`
+
+	RedirectCode  *int    `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	ForwardQuery  *bool   `json:"forward_query,omitempty"`
+	QueryConflict *string `json:"query_conflict,omitempty" validate:"omitempty,oneof=keep override append"`
+	Prefix        *bool   `json:"prefix,omitempty"`
+
+	Title        *string `json:"title,omitempty" validate:"omitempty,max=200"`
+	Description  *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Interstitial *bool   `json:"interstitial,omitempty"`
+}
+
+func (h *UrlHandler) Update(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.Update"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Info("empty alias")
@@ -183,8 +543,71 @@ func (h *UrlHandler) Redirect(log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		resURL, err := h.urlService.GetURL(r.Context(), services.GetURLInput{
-			Alias: alias,
+		var req UrlUpdateInput
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = h.validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		if req.MaxClicks.Value != nil && *req.MaxClicks.Value <= 0 {
+			log.Info("invalid max clicks", slog.Int("max_clicks", *req.MaxClicks.Value))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field MaxClicks must be greater than 0"))
+			return
+		}
+
+		if req.FallbackURL.Value != nil && h.validate.Var(*req.FallbackURL.Value, "url") != nil {
+			log.Info("invalid fallback url", slog.String("fallback_url", *req.FallbackURL.Value))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field FallbackURL is not a valid URL"))
+			return
+		}
+
+		err = h.urlService.UpdateURL(r.Context(), services.UpdateURLInput{
+			Alias:           alias,
+			UserId:          userId,
+			URL:             req.URL,
+			ExpiresAt:       req.ExpiresAt.Value,
+			TTL:             time.Duration(req.TTL) * time.Second,
+			MaxClicks:       req.MaxClicks.Value,
+			ClearExpiration: req.ExpiresAt.isNull(),
+			ClearMaxClicks:  req.MaxClicks.isNull(),
+
+			ActiveFrom:       req.ActiveFrom.Value,
+			ActiveUntil:      req.ActiveUntil.Value,
+			FallbackURL:      req.FallbackURL.Value,
+			ClearActiveFrom:  req.ActiveFrom.isNull(),
+			ClearActiveUntil: req.ActiveUntil.isNull(),
+			ClearFallbackURL: req.FallbackURL.isNull(),
+
+			RedirectCode:  req.RedirectCode,
+			ForwardQuery:  req.ForwardQuery,
+			QueryConflict: req.QueryConflict,
+			Prefix:        req.Prefix,
+
+			Title:        req.Title,
+			Description:  req.Description,
+			Interstitial: req.Interstitial,
 		})
 		if err != nil {
 			if errors.Is(err, services.ErrURLNotFound) {
@@ -194,17 +617,31 @@ func (h *UrlHandler) Redirect(log *slog.Logger) http.HandlerFunc {
 				render.JSON(w, r, resp.Error("url not found"))
 				return
 			}
+			if errors.Is(err, services.ErrInvalidExpiration) {
+				log.Info("invalid expiration", slogHelper.Err(err))
 
-			log.Error("failed to get url", slogHelper.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid expiration"))
+				return
+			}
+			if errors.Is(err, services.ErrInvalidActivationWindow) {
+				log.Info("invalid activation window", slogHelper.Err(err))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid activation window"))
+				return
+			}
+
+			log.Error("failed to update url", slogHelper.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
 			render.JSON(w, r, resp.InternalError())
 			return
 		}
 
-		log.Info("got url", slog.String("url", resURL))
+		log.Info("url updated", "alias", alias)
 
-		http.Redirect(w, r, resURL, http.StatusFound)
+		responseOK(w, r, alias)
 	}
 }
 
@@ -266,3 +703,16 @@ func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
 		Alias:    alias,
 	})
 }
+
+// redirectCacheControl allows caching only permanent redirects of urls that can't stop working by themselves
+// for maxAge. Redirects of expiring, limited, scheduled, protected, targeted or split urls must reach the server
+// every time. Cached redirects don't reach the server, so their clicks aren't counted and edits of the link
+// apply only after maxAge, that's why it's zero (no caching) by default.
+func redirectCacheControl(url *entity.Url, maxAge time.Duration) string {
+	if maxAge <= 0 || !url.IsPermanentRedirect() || url.ExpiresAt != nil || url.MaxClicks != nil ||
+		url.ActiveFrom != nil || url.ActiveUntil != nil || url.IsProtected() || len(url.TargetingRules) != 0 || url.IsSplit() {
+		return "no-store"
+	}
+
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	batchModeAtomic  = "atomic"
+	batchModePartial = "partial"
+)
+
+type batchItemResponse struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Alias  string `json:"alias,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	resp.Response
+	Results []batchItemResponse `json:"results"`
+}
+
+// SaveBatch saves an array of up to services.MaxBatchSize urls. In atomic mode (default) either all urls are saved
+// or none of them, in partial mode (?mode=partial) every url is saved independently. The response contains a result
+// for every url.
+func (h *UrlHandler) SaveBatch(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.SaveBatch"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = batchModeAtomic
+		}
+		if mode != batchModeAtomic && mode != batchModePartial {
+			log.Info("invalid batch mode", slog.String("mode", mode))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		var req []UrlSaveInput
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		log.Info("request body decoded", slog.Int("count", len(req)))
+
+		// the size is checked before validation, so oversized batches don't cost anything
+		if len(req) == 0 || len(req) > services.MaxBatchSize {
+			log.Info("invalid batch size", slog.Int("count", len(req)))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid batch size"))
+			return
+		}
+
+		var (
+			results  = make([]batchItemResponse, len(req))
+			inputs   []services.SaveURLInput
+			indexes  []int
+			validate = h.validate
+		)
+		for i, item := range req {
+			results[i].Index = i
+
+			if err = validate.Struct(item); err != nil {
+				var validateErr validator.ValidationErrors
+				errors.As(err, &validateErr)
+
+				results[i].Status = resp.StatusError
+				results[i].Error = resp.ValidationError(validateErr).Error
+				continue
+			}
+
+			inputs = append(inputs, services.SaveURLInput{
+				URL:          item.URL,
+				Alias:        item.Alias,
+				UserId:       userId,
+				ExpiresAt:    item.ExpiresAt,
+				TTL:          time.Duration(item.TTL) * time.Second,
+				MaxClicks:    item.MaxClicks,
+				Password:     item.Password,
+				ActiveFrom:   item.ActiveFrom,
+				ActiveUntil:  item.ActiveUntil,
+				FallbackURL:  item.FallbackURL,
+				RedirectCode: item.RedirectCode,
+
+				ForwardQuery:  item.ForwardQuery,
+				QueryConflict: item.QueryConflict,
+				Prefix:        item.Prefix,
+
+				UTM:         item.UTM.params(),
+				UTMTemplate: item.UTMTemplate,
+				Variants:    variantsInput(item.Variants),
+
+				Title:        item.Title,
+				Description:  item.Description,
+				Interstitial: item.Interstitial,
+			})
+			indexes = append(indexes, i)
+		}
+
+		if mode == batchModeAtomic && len(inputs) != len(req) {
+			log.Info("invalid urls in atomic batch")
+
+			markAborted(results)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, batchResponse{
+				Response: resp.Error("batch is not saved"),
+				Results:  results,
+			})
+			return
+		}
+
+		var saveResults []services.SaveURLResult
+		if len(inputs) != 0 {
+			saveResults, err = h.urlService.SaveURLs(r.Context(), services.SaveURLsInput{
+				Urls:   inputs,
+				Atomic: mode == batchModeAtomic,
+			})
+		}
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidBatchSize) {
+				log.Info("invalid batch size", slog.Int("count", len(req)))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid batch size"))
+				return
+			}
+
+			log.Error("failed to save urls", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		failed := false
+		for i, res := range saveResults {
+			item := &results[indexes[i]]
+			if res.Err != nil {
+				failed = true
+				item.Status = resp.StatusError
+				item.Error = saveErrorMessage(res.Err)
+				continue
+			}
+
+			item.Status = resp.StatusOK
+			item.Alias = res.Alias
+		}
+
+		if mode == batchModeAtomic && failed {
+			log.Info("atomic batch is not saved")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, batchResponse{
+				Response: resp.Error("batch is not saved"),
+				Results:  results,
+			})
+			return
+		}
+
+		log.Info("urls batch saved", slog.Int("count", len(req)))
+
+		render.JSON(w, r, batchResponse{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
+
+// markAborted marks valid items of a failed atomic batch as aborted.
+func markAborted(results []batchItemResponse) {
+	for i := range results {
+		if results[i].Status == "" {
+			results[i].Status = resp.StatusError
+			results[i].Error = saveErrorMessage(services.ErrBatchAborted)
+		}
+	}
+}
+
+// saveErrorMessage converts an error of saving url to a message that is safe to show to the user.
+func saveErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrAliasAlreadyExists):
+		return "alias already exists"
+	case errors.Is(err, services.ErrInvalidExpiration):
+		return "invalid expiration"
+	case errors.Is(err, services.ErrInvalidActivationWindow):
+		return "invalid activation window"
+	case errors.Is(err, services.ErrInvalidRedirectCode):
+		return "invalid redirect code"
+	case errors.Is(err, services.ErrInvalidQueryConflict):
+		return "invalid query conflict rule"
+	case errors.Is(err, services.ErrUtmTemplateNotFound):
+		return "utm template not found"
+	case errors.Is(err, services.ErrInvalidURLVariants):
+		return "invalid url variants"
+	case errors.Is(err, services.ErrBatchAborted):
+		return "not saved because another url of the batch failed"
+	default:
+		return resp.InternalErrorMessage
+	}
+}
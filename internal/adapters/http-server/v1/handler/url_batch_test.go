@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/api"
+	"github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveBatchHandler(t *testing.T) {
+	const body = `[{"url": "https://google.com"}, {"url": "invalid"}, {"url": "https://ya.ru", "alias": "taken"}]`
+
+	tests := []struct {
+		name        string
+		mode        string
+		mockResults []services.SaveURLResult
+		status      string
+		results     []batchItemResponse
+	}{
+		{
+			name:   "atomic batch with invalid url",
+			mode:   batchModeAtomic,
+			status: response.StatusError,
+			results: []batchItemResponse{
+				{Index: 0, Status: response.StatusError, Error: "not saved because another url of the batch failed"},
+				{Index: 1, Status: response.StatusError, Error: "field URL is not a valid URL"},
+				{Index: 2, Status: response.StatusError, Error: "not saved because another url of the batch failed"},
+			},
+		},
+		{
+			name: "partial batch",
+			mode: batchModePartial,
+			mockResults: []services.SaveURLResult{
+				{Alias: "abcdef"},
+				{Err: services.ErrAliasAlreadyExists},
+			},
+			status: response.StatusOK,
+			results: []batchItemResponse{
+				{Index: 0, Status: response.StatusOK, Alias: "abcdef"},
+				{Index: 1, Status: response.StatusError, Error: "field URL is not a valid URL"},
+				{Index: 2, Status: response.StatusError, Error: "alias already exists"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+
+			if tc.mockResults != nil {
+				urlService.On("SaveURLs", mock.Anything, services.SaveURLsInput{
+					Urls: []services.SaveURLInput{
+						{URL: "https://google.com", UserId: 1},
+						{URL: "https://ya.ru", Alias: "taken", UserId: 1},
+					},
+				}).Return(tc.mockResults, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Post("/api/v1/urls/batch", NewUrlHandler(urlService, nil, aliaspolicy.Default(), InactivePage{}, 0, nil).SaveBatch(slogdiscard.NewDiscardLogger()))
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			respBody, err := api.SendRequest(http.MethodPost, ts.URL+"/api/v1/urls/batch?mode="+tc.mode, strings.NewReader(body))
+			require.NoError(t, err)
+
+			var resp batchResponse
+			require.NoError(t, json.Unmarshal(respBody, &resp))
+			require.Equal(t, tc.status, resp.Status)
+			require.Equal(t, tc.results, resp.Results)
+		})
+	}
+}
+
+func TestSaveBatchHandlerInvalidSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{name: "empty", count: 0},
+		{name: "too large", count: services.MaxBatchSize + 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			items := make([]string, tc.count)
+			for i := range items {
+				items[i] = `{"url": "https://google.com", "password": "secret"}`
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Post("/api/v1/urls/batch", NewUrlHandler(mocks.NewUrlService(t), nil, aliaspolicy.Default(), InactivePage{}, 0, nil).SaveBatch(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/batch", strings.NewReader("["+strings.Join(items, ",")+"]"))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var resp batchResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "invalid batch size", resp.Error)
+			require.Empty(t, resp.Results)
+		})
+	}
+}
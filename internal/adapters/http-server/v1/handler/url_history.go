@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Kinds of url versions.
+const (
+	urlVersionKindURL      = "url"
+	urlVersionKindVariants = "variants"
+)
+
+type urlVersionVariantResponse struct {
+	Id     int    `json:"id"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+type urlVersionResponse struct {
+	Version int `json:"version"`
+	// Kind is "url" for destination changes and "variants" for changes of split-testing variants
+	Kind   string `json:"kind"`
+	OldURL string `json:"old_url,omitempty"`
+	NewURL string `json:"new_url"`
+	// Variants are set by the version of "variants" kind, they're empty if splitting is stopped
+	Variants  []urlVersionVariantResponse `json:"variants,omitempty"`
+	ChangedBy int                         `json:"changed_by"`
+	ChangedAt time.Time                   `json:"changed_at"`
+}
+
+func newURLVersionResponse(version entity.UrlVersion) urlVersionResponse {
+	res := urlVersionResponse{
+		Version:   version.Version,
+		Kind:      urlVersionKindURL,
+		OldURL:    version.OldUrl,
+		NewURL:    version.NewUrl,
+		ChangedBy: version.ChangedBy,
+		ChangedAt: version.ChangedAt,
+	}
+
+	if version.IsVariantsChange() {
+		res.Kind = urlVersionKindVariants
+		for _, variant := range version.Variants {
+			res.Variants = append(res.Variants, urlVersionVariantResponse{
+				Id:     variant.Id,
+				URL:    variant.Url,
+				Weight: variant.Weight,
+			})
+		}
+	}
+
+	return res
+}
+
+type urlHistoryResponse struct {
+	resp.Response
+	Alias    string               `json:"alias"`
+	Versions []urlVersionResponse `json:"versions"`
+}
+
+func (h *UrlHandler) History(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.History"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		versions, err := h.urlService.GetURLHistory(r.Context(), services.GetURLHistoryInput{
+			Alias:  alias,
+			UserId: userId,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrURLNotFound) {
+				log.Info("url not found", "alias", alias)
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("url not found"))
+				return
+			}
+
+			log.Error("failed to get url history", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		res := make([]urlVersionResponse, 0, len(versions))
+		for _, version := range versions {
+			res = append(res, newURLVersionResponse(version))
+		}
+
+		log.Info("url history fetched", "alias", alias)
+
+		render.JSON(w, r, urlHistoryResponse{
+			Response: resp.OK(),
+			Alias:    alias,
+			Versions: res,
+		})
+	}
+}
+
+func (h *UrlHandler) Rollback(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.Rollback"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		version, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if alias == "" || err != nil {
+			log.Info("invalid alias or version")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		err = h.urlService.RollbackURL(r.Context(), services.RollbackURLInput{
+			Alias:   alias,
+			UserId:  userId,
+			Version: version,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrURLNotFound) {
+				log.Info("url not found", "alias", alias)
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("url not found"))
+				return
+			}
+			if errors.Is(err, services.ErrURLVersionNotFound) {
+				log.Info("url version not found", "alias", alias, "version", version)
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("url version not found"))
+				return
+			}
+
+			log.Error("failed to rollback url", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("url rolled back", "alias", alias, "version", version)
+
+		responseOK(w, r, alias)
+	}
+}
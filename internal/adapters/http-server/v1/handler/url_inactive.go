@@ -0,0 +1,75 @@
+package handler
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/services"
+)
+
+//go:embed templates/placeholder.html
+var placeholderHTML string
+
+var defaultPlaceholder = template.Must(template.New("placeholder").Parse(placeholderHTML))
+
+// InactivePage is shown for links outside of their activation window that have no own fallback url.
+// If FallbackURL is set the client is redirected there, otherwise Placeholder is rendered.
+// Placeholder gets Alias, ActiveFrom (*time.Time) and Ended fields, nil means the default one.
+type InactivePage struct {
+	FallbackURL string
+	Placeholder *template.Template
+}
+
+// ParsePlaceholder parses placeholder template from the file.
+func ParsePlaceholder(path string) (*template.Template, error) {
+	const op = "v1.handler.ParsePlaceholder"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tmpl, err := template.New("placeholder").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tmpl, nil
+}
+
+func (p InactivePage) serve(w http.ResponseWriter, r *http.Request, alias string, err *services.InactiveURLError) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	fallback := err.FallbackURL
+	if fallback == "" {
+		fallback = p.FallbackURL
+	}
+
+	if fallback != "" {
+		http.Redirect(w, r, fallback, http.StatusFound)
+		return
+	}
+
+	placeholder := p.Placeholder
+	if placeholder == nil {
+		placeholder = defaultPlaceholder
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	_ = placeholder.Execute(w, struct {
+		Alias      string
+		ActiveFrom *time.Time
+		Ended      bool
+	}{
+		Alias:      alias,
+		ActiveFrom: err.ActiveFrom,
+		Ended:      errors.Is(err, services.ErrURLNoLongerActive),
+	})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandlerInactiveURL(t *testing.T) {
+	activeFrom := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		err        *services.InactiveURLError
+		page       InactivePage
+		statusCode int
+		location   string
+		body       string
+	}{
+		{
+			name:       "not yet active placeholder",
+			err:        &services.InactiveURLError{Err: services.ErrURLNotYetActive, ActiveFrom: &activeFrom},
+			statusCode: http.StatusOK,
+			body:       "It will be available from 2030-01-01 12:00 UTC",
+		},
+		{
+			name:       "no longer active placeholder",
+			err:        &services.InactiveURLError{Err: services.ErrURLNoLongerActive},
+			statusCode: http.StatusOK,
+			body:       "is no longer active",
+		},
+		{
+			name:       "link fallback",
+			err:        &services.InactiveURLError{Err: services.ErrURLNotYetActive, FallbackURL: "https://example.com/soon"},
+			page:       InactivePage{FallbackURL: "https://example.com/"},
+			statusCode: http.StatusFound,
+			location:   "https://example.com/soon",
+		},
+		{
+			name:       "global fallback",
+			err:        &services.InactiveURLError{Err: services.ErrURLNoLongerActive},
+			page:       InactivePage{FallbackURL: "https://example.com/"},
+			statusCode: http.StatusFound,
+			location:   "https://example.com/",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+			urlService.On("GetURL", mock.Anything, services.GetURLInput{Alias: "launch"}).Return(nil, tc.err).Once()
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, nil, aliaspolicy.Default(), tc.page, 0, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			httpResp := sendWithoutRedirect(t, http.MethodGet, ts.URL+"/api/v1/urls/launch")
+			require.Equal(t, tc.statusCode, httpResp.StatusCode)
+			require.Equal(t, "no-store", httpResp.Header.Get("Cache-Control"))
+
+			if tc.location != "" {
+				require.Equal(t, tc.location, httpResp.Header.Get("Location"))
+				return
+			}
+
+			body, err := io.ReadAll(httpResp.Body)
+			require.NoError(t, err)
+			require.Contains(t, string(body), tc.body)
+		})
+	}
+}
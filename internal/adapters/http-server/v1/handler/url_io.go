@@ -0,0 +1,552 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+
+	maxImportSize = 10 << 20
+	// maxImportRows bounds the number of urls saved by one request, every url is saved separately.
+	maxImportRows = 1000
+	importFileKey = "file"
+
+	exportFlushEvery = 100
+)
+
+var (
+	errUnknownFormat          = errors.New("unknown format")
+	errNoURLColumn            = errors.New("no url column")
+	errTooManyImportRows      = fmt.Errorf("file has more than %d urls", maxImportRows)
+	errInvalidImportExpiresAt = errors.New("field expires_at is not a valid RFC 3339 time")
+	errInvalidImportMaxClicks = errors.New("field max_clicks is not a valid number")
+)
+
+var exportCSVHeader = []string{"alias", "url", "created_at", "expires_at", "max_clicks", "clicks"}
+
+// Export streams all user urls as csv (default) or json array depending on format query param.
+func (h *UrlHandler) Export(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.Export"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = formatCSV
+		}
+
+		var exporter urlsExporter
+		switch format {
+		case formatCSV:
+			exporter = newCSVExporter(w)
+		case formatJSON:
+			exporter = newJSONExporter(w)
+		default:
+			log.Info("unknown export format", slog.String("format", format))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("unknown format"))
+			return
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="urls.%s"`, format))
+		exporter.begin()
+
+		err := h.urlService.ExportUserURLs(r.Context(), services.ExportUserURLsInput{UserId: userId}, exporter.write)
+		if err == nil {
+			err = exporter.end()
+		}
+		if err != nil {
+			// the response is already started, so the client gets a truncated file
+			log.Error("failed to export urls", slogHelper.Err(err))
+			return
+		}
+
+		log.Info("urls exported", slog.String("format", format))
+	}
+}
+
+type urlsExporter interface {
+	begin()
+	write(url *entity.Url) error
+	end() error
+}
+
+type csvExporter struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer
+	written int
+}
+
+func newCSVExporter(w http.ResponseWriter) *csvExporter {
+	return &csvExporter{w: w, csv: csv.NewWriter(w)}
+}
+
+func (e *csvExporter) begin() {
+	e.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	_ = e.csv.Write(exportCSVHeader)
+}
+
+func (e *csvExporter) write(url *entity.Url) error {
+	var expiresAt, maxClicks string
+	if url.ExpiresAt != nil {
+		expiresAt = url.ExpiresAt.Format(time.RFC3339)
+	}
+	if url.MaxClicks != nil {
+		maxClicks = strconv.Itoa(*url.MaxClicks)
+	}
+
+	err := e.csv.Write([]string{
+		url.Alias,
+		url.Url,
+		url.CreatedAt.Format(time.RFC3339),
+		expiresAt,
+		maxClicks,
+		strconv.Itoa(url.ClickCount),
+	})
+	if err != nil {
+		return err
+	}
+
+	e.written++
+	if e.written%exportFlushEvery == 0 {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	return nil
+}
+
+func (e *csvExporter) end() error {
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+type jsonExporter struct {
+	w       http.ResponseWriter
+	written int
+}
+
+func newJSONExporter(w http.ResponseWriter) *jsonExporter {
+	return &jsonExporter{w: w}
+}
+
+func (e *jsonExporter) begin() {
+	e.w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(e.w, "[")
+}
+
+func (e *jsonExporter) write(url *entity.Url) error {
+	data, err := json.Marshal(urlResponse{
+		Alias:     url.Alias,
+		URL:       url.Url,
+		CreatedAt: url.CreatedAt,
+		ExpiresAt: url.ExpiresAt,
+		MaxClicks: url.MaxClicks,
+		Clicks:    url.ClickCount,
+	})
+	if err != nil {
+		return err
+	}
+
+	if e.written != 0 {
+		data = append([]byte(","), data...)
+	}
+	e.written++
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExporter) end() error {
+	_, err := io.WriteString(e.w, "]")
+	return err
+}
+
+// Import saves urls from uploaded csv or json file. The file is either sent as multipart form field "file"
+// or as a raw request body. Besides own export format, column names of Bitly export are understood.
+// Files with more than maxImportRows urls are rejected. The response contains a result for every imported url.
+func (h *UrlHandler) Import(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.Import"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+		file, format, err := importFile(r)
+		if err != nil {
+			log.Info("failed to get import file", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+		defer file.Close()
+
+		var rows []importedURL
+		switch format {
+		case formatCSV:
+			rows, err = parseImportCSV(file)
+		case formatJSON:
+			rows, err = parseImportJSON(file)
+		}
+		if errors.Is(err, errTooManyImportRows) {
+			log.Info("too many urls in import file")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			log.Info("failed to parse import file", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to parse file"))
+			return
+		}
+
+		log.Info("import file parsed", slog.String("format", format), slog.Int("count", len(rows)))
+
+		var (
+			results  = make([]batchItemResponse, len(rows))
+			inputs   []services.SaveURLInput
+			indexes  []int
+			validate = h.validate
+		)
+		for i, row := range rows {
+			results[i].Index = i
+
+			if row.err != nil {
+				results[i].Status = resp.StatusError
+				results[i].Error = row.err.Error()
+				continue
+			}
+
+			item := UrlSaveInput{
+				URL:       row.url,
+				Alias:     row.alias,
+				ExpiresAt: row.expiresAt,
+				MaxClicks: row.maxClicks,
+			}
+			if err = validate.Struct(item); err != nil {
+				var validateErr validator.ValidationErrors
+				errors.As(err, &validateErr)
+
+				results[i].Status = resp.StatusError
+				results[i].Error = resp.ValidationError(validateErr).Error
+				continue
+			}
+
+			inputs = append(inputs, services.SaveURLInput{
+				URL:       item.URL,
+				Alias:     item.Alias,
+				UserId:    userId,
+				ExpiresAt: item.ExpiresAt,
+				MaxClicks: item.MaxClicks,
+			})
+			indexes = append(indexes, i)
+		}
+
+		for i, res := range h.urlService.ImportURLs(r.Context(), inputs) {
+			item := &results[indexes[i]]
+			if res.Err != nil {
+				if !errors.Is(res.Err, services.ErrAliasAlreadyExists) && !errors.Is(res.Err, services.ErrInvalidExpiration) &&
+					!errors.Is(res.Err, services.ErrInvalidActivationWindow) {
+					log.Error("failed to import url", slogHelper.Err(res.Err))
+				}
+
+				item.Status = resp.StatusError
+				item.Error = saveErrorMessage(res.Err)
+				continue
+			}
+
+			item.Status = resp.StatusOK
+			item.Alias = res.Alias
+		}
+
+		log.Info("urls imported", slog.Int("count", len(rows)))
+
+		render.JSON(w, r, batchResponse{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
+
+// importFile returns uploaded file and its format. The format is taken from format query param,
+// content type or file extension.
+func importFile(r *http.Request) (io.ReadCloser, string, error) {
+	format := r.URL.Query().Get("format")
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if format == "" {
+			format = formatByContentType(mediaType)
+		}
+		if format != formatCSV && format != formatJSON {
+			return nil, "", errUnknownFormat
+		}
+
+		return r.Body, format, nil
+	}
+
+	file, header, err := r.FormFile(importFileKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == "" {
+		format = formatByContentType(header.Header.Get("Content-Type"))
+	}
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(path.Ext(header.Filename)), ".")
+	}
+	if format != formatCSV && format != formatJSON {
+		_ = file.Close()
+		return nil, "", errUnknownFormat
+	}
+
+	return file, format, nil
+}
+
+func formatByContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/csv":
+		return formatCSV
+	case "application/json":
+		return formatJSON
+	}
+
+	return ""
+}
+
+// importedURL is a parsed row of an imported file. Rows that can't be parsed have err set.
+type importedURL struct {
+	url       string
+	alias     string
+	expiresAt *time.Time
+	maxClicks *int
+	err       error
+}
+
+const (
+	importURL = iota
+	importAlias
+	importShortLink
+	importExpiresAt
+	importMaxClicks
+)
+
+// importColumns maps known column names, including Bitly ones, to imported fields.
+var importColumns = map[string]int{
+	"url":            importURL,
+	"long_url":       importURL,
+	"destination":    importURL,
+	"original_url":   importURL,
+	"alias":          importAlias,
+	"custom_bitlink": importAlias,
+	"back_half":      importAlias,
+	"keyword":        importAlias,
+	"link":           importShortLink,
+	"bitlink":        importShortLink,
+	"short_url":      importShortLink,
+	"expires_at":     importExpiresAt,
+	"max_clicks":     importMaxClicks,
+}
+
+func parseImportCSV(r io.Reader) ([]importedURL, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[int]int)
+	for i, name := range header {
+		if field, ok := importColumns[importColumnName(name)]; ok {
+			if _, exists := columns[field]; !exists {
+				columns[field] = i
+			}
+		}
+	}
+
+	if _, ok := columns[importURL]; !ok {
+		return nil, errNoURLColumn
+	}
+
+	var rows []importedURL
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+
+		values := make(map[int]string, len(columns))
+		for field, i := range columns {
+			if i < len(record) {
+				values[field] = strings.TrimSpace(record[i])
+			}
+		}
+
+		rows = append(rows, newImportedURL(values))
+	}
+
+	return rows, nil
+}
+
+// parseImportJSON parses either an array of urls or Bitly-like object with links array.
+func parseImportJSON(r io.Reader) ([]importedURL, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []map[string]any
+	if err = json.Unmarshal(data, &objects); err != nil {
+		var wrapped struct {
+			Links []map[string]any `json:"links"`
+		}
+		if err = json.Unmarshal(data, &wrapped); err != nil {
+			return nil, err
+		}
+		objects = wrapped.Links
+	}
+
+	if len(objects) > maxImportRows {
+		return nil, errTooManyImportRows
+	}
+
+	rows := make([]importedURL, 0, len(objects))
+	for _, object := range objects {
+		values := make(map[int]string)
+		for key, value := range object {
+			field, ok := importColumns[importColumnName(key)]
+			if !ok {
+				if key != "custom_bitlinks" {
+					continue
+				}
+				field = importAlias
+			}
+
+			if _, exists := values[field]; !exists {
+				values[field] = jsonImportValue(value)
+			}
+		}
+
+		rows = append(rows, newImportedURL(values))
+	}
+
+	return rows, nil
+}
+
+// jsonImportValue converts json value to string. Of arrays only the first element is used.
+func jsonImportValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		if len(v) != 0 {
+			return jsonImportValue(v[0])
+		}
+	}
+
+	return ""
+}
+
+// importColumnName normalizes column name, so "Long URL", "long-url" and "long_url" are the same column.
+func importColumnName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+func newImportedURL(values map[int]string) importedURL {
+	row := importedURL{url: values[importURL]}
+
+	// short links like https://bit.ly/abc are imported with the same back half
+	row.alias = values[importAlias]
+	if row.alias == "" {
+		row.alias = values[importShortLink]
+	}
+	if i := strings.LastIndex(row.alias, "/"); i != -1 {
+		row.alias = row.alias[i+1:]
+	}
+
+	if v := values[importExpiresAt]; v != "" {
+		expiresAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			row.err = errInvalidImportExpiresAt
+			return row
+		}
+		row.expiresAt = &expiresAt
+	}
+
+	if v := values[importMaxClicks]; v != "" {
+		maxClicks, err := strconv.Atoi(v)
+		if err != nil {
+			row.err = errInvalidImportMaxClicks
+			return row
+		}
+		row.maxClicks = &maxClicks
+	}
+
+	return row
+}
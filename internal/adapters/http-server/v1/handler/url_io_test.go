@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	maxClicks := 5
+
+	tests := []struct {
+		name    string
+		file    string
+		rows    []importedURL
+		wantErr bool
+	}{
+		{
+			name: "own export format",
+			file: "alias,url,created_at,expires_at,max_clicks,clicks\n" +
+				"abc,https://google.com,2024-06-01T00:00:00Z,2030-01-02T03:04:05Z,5,1\n" +
+				"def,https://ya.ru,2024-06-01T00:00:00Z,,,0\n",
+			rows: []importedURL{
+				{url: "https://google.com", alias: "abc", expiresAt: &expiresAt, maxClicks: &maxClicks},
+				{url: "https://ya.ru", alias: "def"},
+			},
+		},
+		{
+			name: "bitly export",
+			file: "\uFEFFTitle,Link,Custom Bitlink,Long_URL,Created_At\n" +
+				"Google,https://bit.ly/3xYz,,https://google.com,2021-01-01T00:00:00+0000\n" +
+				"Ya,https://bit.ly/4aBc,bit.ly/my-ya,https://ya.ru,2021-01-01T00:00:00+0000\n",
+			rows: []importedURL{
+				{url: "https://google.com", alias: "3xYz"},
+				{url: "https://ya.ru", alias: "my-ya"},
+			},
+		},
+		{
+			name: "invalid expires_at",
+			file: "url,expires_at\nhttps://google.com,tomorrow\n",
+			rows: []importedURL{
+				{url: "https://google.com", err: errInvalidImportExpiresAt},
+			},
+		},
+		{
+			name:    "no url column",
+			file:    "alias,title\nabc,Google\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, err := parseImportCSV(strings.NewReader(tc.file))
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.rows, rows)
+		})
+	}
+}
+
+func TestParseImportJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		rows []importedURL
+	}{
+		{
+			name: "array of urls",
+			file: `[{"alias": "abc", "url": "https://google.com", "clicks": 10}, {"url": "https://ya.ru"}]`,
+			rows: []importedURL{
+				{url: "https://google.com", alias: "abc"},
+				{url: "https://ya.ru"},
+			},
+		},
+		{
+			name: "bitly links",
+			file: `{"links": [
+				{"link": "https://bit.ly/3xYz", "long_url": "https://google.com", "custom_bitlinks": []},
+				{"link": "https://bit.ly/4aBc", "long_url": "https://ya.ru", "custom_bitlinks": ["https://bit.ly/my-ya"]}
+			]}`,
+			rows: []importedURL{
+				{url: "https://google.com", alias: "3xYz"},
+				{url: "https://ya.ru", alias: "my-ya"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, err := parseImportJSON(strings.NewReader(tc.file))
+			require.NoError(t, err)
+			require.Equal(t, tc.rows, rows)
+		})
+	}
+}
+
+func TestParseImportTooManyRows(t *testing.T) {
+	t.Parallel()
+
+	csvFile := "url\n" + strings.Repeat("https://google.com\n", maxImportRows)
+	rows, err := parseImportCSV(strings.NewReader(csvFile))
+	require.NoError(t, err)
+	require.Len(t, rows, maxImportRows)
+
+	_, err = parseImportCSV(strings.NewReader(csvFile + "https://ya.ru\n"))
+	require.ErrorIs(t, err, errTooManyImportRows)
+
+	jsonFile := "[" + strings.Repeat(`{"url": "https://google.com"},`, maxImportRows) + `{"url": "https://ya.ru"}]`
+	_, err = parseImportJSON(strings.NewReader(jsonFile))
+	require.ErrorIs(t, err, errTooManyImportRows)
+}
+
+func TestImportHandlerTooManyRows(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	r.Use(withUserId("1"))
+	r.Post("/api/v1/urls/import", NewUrlHandler(mocks.NewUrlService(t), nil, aliaspolicy.Default(), InactivePage{}, 0, nil).Import(slogdiscard.NewDiscardLogger()))
+
+	file := "url\n" + strings.Repeat("https://google.com\n", maxImportRows+1)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls/import", strings.NewReader(file))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var resp response.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, errTooManyImportRows.Error(), resp.Error)
+}
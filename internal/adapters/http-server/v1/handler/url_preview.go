@@ -0,0 +1,135 @@
+package handler
+
+import (
+	_ "embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/4aykovski/url_shortener/pkg/urlsafety"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	// previewSuffix appended to the alias shows the preview page instead of redirect, as well as previewParam.
+	previewSuffix = "+"
+	previewParam  = "preview"
+	// confirmParam is set by the continue link of the preview page, it skips the interstitial page.
+	confirmParam = "confirm"
+)
+
+//go:embed templates/preview.html
+var previewPageHTML string
+
+var previewPage = template.Must(template.New("preview").Parse(previewPageHTML))
+
+type safetyResponse struct {
+	Status   string   `json:"status"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type previewResponse struct {
+	resp.Response
+	Alias        string         `json:"alias"`
+	URL          string         `json:"url"`
+	Title        string         `json:"title,omitempty"`
+	Description  string         `json:"description,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Safety       safetyResponse `json:"safety"`
+	Interstitial bool           `json:"interstitial"`
+	ContinueURL  string         `json:"continue_url"`
+}
+
+// previewAlias returns the alias of the request and reports whether its preview is requested.
+func previewAlias(r *http.Request) (string, bool) {
+	alias := chi.URLParam(r, "alias")
+	if trimmed, ok := strings.CutSuffix(alias, previewSuffix); ok {
+		return trimmed, true
+	}
+
+	return alias, r.URL.Query().Get(previewParam) == "1"
+}
+
+// preview shows the preview page of the url without counting a click.
+func (h *UrlHandler) preview(w http.ResponseWriter, r *http.Request, input services.GetURLInput, log *slog.Logger) {
+	url, err := h.urlService.PreviewURL(r.Context(), input)
+	if err != nil {
+		h.renderGetURLError(w, r, input.Alias, err, log)
+		return
+	}
+
+	log.Info("url previewed", slog.String("alias", input.Alias))
+
+	query := r.URL.Query()
+	query.Del(previewParam)
+	query.Del(confirmParam)
+
+	renderPreview(w, r, url, input.Path, query, log)
+}
+
+// renderPreview renders the preview page as json if the client accepts it, otherwise as html.
+// Query is the query of the short link without preview params.
+func renderPreview(w http.ResponseWriter, r *http.Request, u *entity.Url, path string, query url.Values, log *slog.Logger) {
+	destination, err := u.Destination(path, query)
+	if err != nil {
+		log.Error("failed to build destination", slogHelper.Err(err))
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.InternalError())
+		return
+	}
+
+	report := urlsafety.Check(destination)
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	preview := previewResponse{
+		Response:    resp.OK(),
+		Alias:       u.Alias,
+		URL:         destination,
+		Title:       u.Title,
+		Description: u.Description,
+		CreatedAt:   u.CreatedAt,
+		Safety: safetyResponse{
+			Status:   report.Status,
+			Warnings: report.Warnings,
+		},
+		Interstitial: u.Interstitial,
+		ContinueURL:  continueURL(r, path, query),
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, preview)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	_ = previewPage.Execute(w, preview)
+}
+
+// continueURL returns the short link that redirects to the destination skipping the interstitial page.
+func continueURL(r *http.Request, path string, query url.Values) string {
+	link := strings.TrimSuffix(aliasPath(r), previewSuffix)
+	if path != "" {
+		link += "/" + path
+	}
+
+	params := make(url.Values, len(query)+1)
+	for key, values := range query {
+		params[key] = values
+	}
+	params.Set(confirmParam, "1")
+
+	return link + "?" + params.Encode()
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/4aykovski/url_shortener/pkg/urlsafety"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandlerPreview(t *testing.T) {
+	url := &entity.Url{
+		Id:           1,
+		Alias:        "ab",
+		Url:          "http://example.com",
+		Title:        "Example",
+		Description:  "Example domain",
+		CreatedAt:    time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC),
+		RedirectCode: http.StatusFound,
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{name: "suffix", target: "/api/v1/urls/ab+", accept: "application/json"},
+		{name: "query param", target: "/api/v1/urls/ab?preview=1", accept: "application/json"},
+		{name: "html", target: "/api/v1/urls/ab+", accept: "text/html"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+
+			urlService.On("PreviewURL", mock.Anything, services.GetURLInput{Alias: "ab"}).
+				Return(url, nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, mocks.NewClickService(t), aliaspolicy.Default(), InactivePage{}, 0, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req.Header.Set("Accept", tc.accept)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			if tc.accept != "application/json" {
+				require.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+				require.Contains(t, rr.Body.String(), "http://example.com")
+				require.Contains(t, rr.Body.String(), template.HTMLEscapeString(urlsafety.WarningNoTLS))
+				return
+			}
+
+			var resp previewResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, "http://example.com", resp.URL)
+			require.Equal(t, "Example", resp.Title)
+			require.Equal(t, "Example domain", resp.Description)
+			require.Equal(t, url.CreatedAt, resp.CreatedAt)
+			require.Equal(t, urlsafety.StatusWarning, resp.Safety.Status)
+			require.Equal(t, []string{urlsafety.WarningNoTLS}, resp.Safety.Warnings)
+			require.Equal(t, "/api/v1/urls/ab?confirm=1", resp.ContinueURL)
+		})
+	}
+}
+
+func TestRedirectHandlerInterstitial(t *testing.T) {
+	url := &entity.Url{
+		Id:           1,
+		Alias:        "ab",
+		Url:          "https://example.com",
+		RedirectCode: http.StatusFound,
+		Interstitial: true,
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		confirmed  bool
+		respStatus int
+	}{
+		{name: "not confirmed", target: "/api/v1/urls/ab?utm_source=x", respStatus: http.StatusOK},
+		{name: "confirmed", target: "/api/v1/urls/ab?utm_source=x&confirm=1", confirmed: true, respStatus: http.StatusFound},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+			clickService := mocks.NewClickService(t)
+
+			input := services.GetURLInput{Alias: "ab", SkipInterstitial: tc.confirmed}
+			if tc.confirmed {
+				urlService.On("GetURL", mock.Anything, input).Return(url, nil).Once()
+				clickService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Once()
+			} else {
+				urlService.On("GetURL", mock.Anything, input).
+					Return(nil, &services.InterstitialError{URL: url}).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, clickService, aliaspolicy.Default(), InactivePage{}, 0, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req.Header.Set("Accept", "application/json")
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+
+			if tc.confirmed {
+				require.Equal(t, "https://example.com", rr.Header().Get("Location"))
+				return
+			}
+
+			var resp previewResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.True(t, resp.Interstitial)
+			require.Equal(t, urlsafety.StatusOK, resp.Safety.Status)
+			require.Equal(t, "/api/v1/urls/ab?confirm=1&utm_source=x", resp.ContinueURL)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const statsDateLayout = time.DateOnly
+
+type dailyClicksResponse struct {
+	Date           string `json:"date"`
+	Clicks         int    `json:"clicks"`
+	UniqueVisitors int    `json:"unique_visitors"`
+}
+
+type variantClicksResponse struct {
+	Id             int    `json:"id"`
+	URL            string `json:"url"`
+	Weight         int    `json:"weight"`
+	Clicks         int    `json:"clicks"`
+	UniqueVisitors int    `json:"unique_visitors"`
+	// Archived variants have been replaced by other variants, their clicks are kept
+	Archived bool `json:"archived,omitempty"`
+}
+
+type urlStatsResponse struct {
+	resp.Response
+	Alias          string                `json:"alias"`
+	TotalClicks    int                   `json:"total_clicks"`
+	UniqueVisitors int                   `json:"unique_visitors"`
+	Daily          []dailyClicksResponse `json:"daily"`
+	// Variants are present only for split-testing urls
+	Variants []variantClicksResponse `json:"variants,omitempty"`
+}
+
+// Stats returns clicks statistics of the url owned by the user.
+// Optional from and to query params (YYYY-MM-DD) bound the daily time series.
+func (h *UrlHandler) Stats(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.Stats"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		from, err := parseStatsDate(r.URL.Query().Get("from"))
+		if err != nil {
+			log.Info("invalid from param", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		to, err := parseStatsDate(r.URL.Query().Get("to"))
+		if err != nil {
+			log.Info("invalid to param", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		stats, err := h.clickService.GetURLStats(r.Context(), services.GetURLStatsInput{
+			Alias:  alias,
+			UserId: userId,
+			From:   from,
+			To:     to,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrURLNotFound) {
+				log.Info("url not found", "alias", alias)
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("url not found"))
+				return
+			}
+			if errors.Is(err, services.ErrInvalidStatsPeriod) {
+				log.Info("invalid stats period", slogHelper.Err(err))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid stats period"))
+				return
+			}
+
+			log.Error("failed to get url stats", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		daily := make([]dailyClicksResponse, 0, len(stats.Daily))
+		for _, day := range stats.Daily {
+			daily = append(daily, dailyClicksResponse{
+				Date:           day.Day.Format(statsDateLayout),
+				Clicks:         day.Clicks,
+				UniqueVisitors: day.UniqueVisitors,
+			})
+		}
+
+		var variants []variantClicksResponse
+		for _, variant := range stats.Variants {
+			variants = append(variants, variantClicksResponse{
+				Id:             variant.VariantId,
+				URL:            variant.Url,
+				Weight:         variant.Weight,
+				Clicks:         variant.Clicks,
+				UniqueVisitors: variant.UniqueVisitors,
+				Archived:       variant.Archived,
+			})
+		}
+
+		log.Info("url stats fetched", "alias", alias)
+
+		render.JSON(w, r, urlStatsResponse{
+			Response:       resp.OK(),
+			Alias:          alias,
+			TotalClicks:    stats.TotalClicks,
+			UniqueVisitors: stats.UniqueVisitors,
+			Daily:          daily,
+			Variants:       variants,
+		})
+	}
+}
+
+func parseStatsDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(statsDateLayout, value)
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/4aykovski/url_shortener/pkg/useragent"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+// TargetingRuleInput is a targeting rule, omitted conditions match any client.
+// Bot set to true matches only bots and set to false matches only people.
+// Country (e.g. US) and region (e.g. US-WA) are ISO 3166 codes, they match only if geoip database is configured.
+type TargetingRuleInput struct {
+	OS      string `json:"os,omitempty" validate:"omitempty,oneof=ios android windows macos linux chromeos other"`
+	Device  string `json:"device,omitempty" validate:"omitempty,oneof=mobile tablet desktop"`
+	Browser string `json:"browser,omitempty" validate:"omitempty,oneof=chrome safari firefox edge opera samsung other"`
+	Bot     *bool  `json:"bot,omitempty"`
+	Country string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	Region  string `json:"region,omitempty" validate:"omitempty,iso3166_2"`
+	URL     string `json:"url" validate:"required,url"`
+}
+
+// TargetingRulesInput replaces all targeting rules of the url. Rules are checked in the given order,
+// clients matching no rule are redirected to the url itself.
+type TargetingRulesInput struct {
+	Rules []TargetingRuleInput `json:"rules" validate:"dive"`
+}
+
+type targetingRulesResponse struct {
+	resp.Response
+	Alias string `json:"alias"`
+	// DefaultURL is used for clients matching no rule
+	DefaultURL string               `json:"default_url"`
+	Rules      []TargetingRuleInput `json:"rules"`
+}
+
+func (h *UrlHandler) GetTargeting(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.GetTargeting"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		url, err := h.urlService.GetTargetingRules(r.Context(), services.GetTargetingRulesInput{
+			Alias:  alias,
+			UserId: userId,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrURLNotFound) {
+				log.Info("url not found", "alias", alias)
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("url not found"))
+				return
+			}
+
+			log.Error("failed to get targeting rules", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		res := make([]TargetingRuleInput, 0, len(url.TargetingRules))
+		for _, rule := range url.TargetingRules {
+			res = append(res, TargetingRuleInput{
+				OS:      rule.OS,
+				Device:  rule.Device,
+				Browser: rule.Browser,
+				Bot:     rule.Bot,
+				Country: rule.Country,
+				Region:  rule.Region,
+				URL:     rule.Url,
+			})
+		}
+
+		log.Info("targeting rules fetched", "alias", alias)
+
+		render.JSON(w, r, targetingRulesResponse{
+			Response:   resp.OK(),
+			Alias:      alias,
+			DefaultURL: url.Url,
+			Rules:      res,
+		})
+	}
+}
+
+func (h *UrlHandler) SetTargeting(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.SetTargeting"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		var req TargetingRulesInput
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		log.Info("request body decoded", slog.Int("rules", len(req.Rules)))
+
+		if err = h.validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		rules := make([]entity.TargetingRule, 0, len(req.Rules))
+		for _, rule := range req.Rules {
+			rules = append(rules, entity.TargetingRule{
+				OS:      rule.OS,
+				Device:  rule.Device,
+				Browser: rule.Browser,
+				Bot:     rule.Bot,
+				Country: rule.Country,
+				Region:  rule.Region,
+				Url:     rule.URL,
+			})
+		}
+
+		h.setTargetingRules(w, r, log, alias, userId, rules)
+	}
+}
+
+// DeleteTargeting removes all targeting rules, so the url always redirects to its own destination.
+func (h *UrlHandler) DeleteTargeting(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.DeleteTargeting"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		h.setTargetingRules(w, r, log, alias, userId, nil)
+	}
+}
+
+func (h *UrlHandler) setTargetingRules(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	alias string,
+	userId int,
+	rules []entity.TargetingRule,
+) {
+	err := h.urlService.SetTargetingRules(r.Context(), services.SetTargetingRulesInput{
+		Alias:  alias,
+		UserId: userId,
+		Rules:  rules,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("url not found"))
+			return
+		}
+		if errors.Is(err, services.ErrEmptyTargetingRule) {
+			log.Info("empty targeting rule", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("targeting rule must have at least one condition"))
+			return
+		}
+		if errors.Is(err, services.ErrTooManyTargetingRules) {
+			log.Info("too many targeting rules", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("too many targeting rules"))
+			return
+		}
+
+		log.Error("failed to set targeting rules", slogHelper.Err(err))
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.InternalError())
+		return
+	}
+
+	log.Info("targeting rules set", "alias", alias, slog.Int("rules", len(rules)))
+
+	render.JSON(w, r, resp.OK())
+}
+
+// client describes the client of the request. Location is looked up only for urls with geo targeting.
+func (h *UrlHandler) client(r *http.Request, url *entity.Url, log *slog.Logger) entity.Client {
+	client := entity.Client{Info: useragent.Parse(r.UserAgent())}
+	if h.geo == nil || !url.HasGeoTargeting() {
+		return client
+	}
+
+	loc, err := h.geo.Lookup(net.ParseIP(clientIP(r)))
+	if err != nil {
+		log.Warn("failed to lookup client location", slogHelper.Err(err))
+		return client
+	}
+
+	client.Country, client.Region = loc.Country, loc.Region
+	return client
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/geoip"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/4aykovski/url_shortener/pkg/useragent"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandlerTargeting(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		location  string
+	}{
+		{
+			name:      "ios",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			location:  "https://apps.apple.com/app/id1",
+		},
+		{
+			name:      "android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+			location:  "https://play.google.com/store/apps/details?id=app",
+		},
+		{
+			name:      "desktop",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			location:  "https://example.com",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+			clickService := mocks.NewClickService(t)
+
+			urlService.On("GetURL", mock.Anything, services.GetURLInput{Alias: "app"}).
+				Return(&entity.Url{
+					Id:           1,
+					Alias:        "app",
+					Url:          "https://example.com",
+					RedirectCode: http.StatusMovedPermanently,
+					TargetingRules: []entity.TargetingRule{
+						{OS: useragent.OSIOS, Url: "https://apps.apple.com/app/id1"},
+						{OS: useragent.OSAndroid, Url: "https://play.google.com/store/apps/details?id=app"},
+					},
+				}, nil).Once()
+			clickService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, clickService, aliaspolicy.Default(), InactivePage{}, 0, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/app", nil)
+			req.Header.Set("User-Agent", tc.userAgent)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusMovedPermanently, rr.Code)
+			require.Equal(t, tc.location, rr.Header().Get("Location"))
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"), "targeted redirects differ per client")
+		})
+	}
+}
+
+type geoLocatorFunc func(ip net.IP) (geoip.Location, error)
+
+func (f geoLocatorFunc) Lookup(ip net.IP) (geoip.Location, error) {
+	return f(ip)
+}
+
+func TestRedirectHandlerGeoTargeting(t *testing.T) {
+	geo := geoLocatorFunc(func(ip net.IP) (geoip.Location, error) {
+		switch ip.String() {
+		case "216.160.83.56":
+			return geoip.Location{Country: "US", Region: "US-WA"}, nil
+		case "81.2.69.160":
+			return geoip.Location{Country: "GB", Region: "GB-ENG"}, nil
+		default:
+			return geoip.Location{}, nil
+		}
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		location   string
+	}{
+		{name: "region", remoteAddr: "216.160.83.56:1234", location: "https://example.com/seattle"},
+		{name: "country", remoteAddr: "81.2.69.160:1234", location: "https://example.co.uk"},
+		{name: "unknown location", remoteAddr: "127.0.0.1:1234", location: "https://example.com"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+			clickService := mocks.NewClickService(t)
+
+			urlService.On("GetURL", mock.Anything, services.GetURLInput{Alias: "shop"}).
+				Return(&entity.Url{
+					Id:           1,
+					Alias:        "shop",
+					Url:          "https://example.com",
+					RedirectCode: http.StatusFound,
+					TargetingRules: []entity.TargetingRule{
+						{Region: "US-WA", Url: "https://example.com/seattle"},
+						{Country: "GB", Url: "https://example.co.uk"},
+					},
+				}, nil).Once()
+			clickService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, clickService, aliaspolicy.Default(), InactivePage{}, 0, geo).Redirect(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/shop", nil)
+			req.RemoteAddr = tc.remoteAddr
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tc.location, rr.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/middleware"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/api"
+	"github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	maxClicks := 10
+
+	tests := []struct {
+		name         string
+		alias        string
+		url          string
+		redirectCode int
+		maxClicks    *int
+		statusCode   int
+		cacheControl string
+		respError    string
+		mockError    error
+	}{
+		{
+			name:         "success redirect",
+			alias:        "test_alias",
+			url:          "https://www.google.com/",
+			redirectCode: http.StatusFound,
+			statusCode:   http.StatusFound,
+			cacheControl: "no-store",
+		},
+		{
+			name:         "permanent redirect",
+			alias:        "test_alias",
+			url:          "https://www.google.com/",
+			redirectCode: http.StatusMovedPermanently,
+			statusCode:   http.StatusMovedPermanently,
+			cacheControl: "public, max-age=86400",
+		},
+		{
+			name:         "permanent redirect preserving method",
+			alias:        "test_alias",
+			url:          "https://www.google.com/",
+			redirectCode: http.StatusPermanentRedirect,
+			statusCode:   http.StatusPermanentRedirect,
+			cacheControl: "public, max-age=86400",
+		},
+		{
+			name:         "permanent redirect with clicks limit",
+			alias:        "test_alias",
+			url:          "https://www.google.com/",
+			redirectCode: http.StatusMovedPermanently,
+			maxClicks:    &maxClicks,
+			statusCode:   http.StatusMovedPermanently,
+			cacheControl: "no-store",
+		},
+		{
+			name:         "temporary redirect preserving method",
+			alias:        "test_alias",
+			url:          "https://www.google.com/",
+			redirectCode: http.StatusTemporaryRedirect,
+			statusCode:   http.StatusTemporaryRedirect,
+			cacheControl: "no-store",
+		},
+		{
+			name:       "url not found",
+			alias:      "not_found",
+			statusCode: http.StatusBadRequest,
+			respError:  "url not found",
+			mockError:  services.ErrURLNotFound,
+		},
+		{
+			name:       "url expired",
+			alias:      "expired",
+			statusCode: http.StatusGone,
+			respError:  "url expired",
+			mockError:  services.ErrURLExpired,
+		},
+		{
+			name:       "url clicks limit exhausted",
+			alias:      "exhausted",
+			statusCode: http.StatusGone,
+			respError:  "url clicks limit exhausted",
+			mockError:  services.ErrURLClicksExhausted,
+		},
+		{
+			name:       "unexpected error",
+			alias:      "test_alias",
+			statusCode: http.StatusInternalServerError,
+			respError:  response.InternalErrorMessage,
+			mockError:  errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+			clickService := mocks.NewClickService(t)
+
+			if tc.mockError != nil {
+				urlService.On("GetURL", mock.Anything, services.GetURLInput{Alias: tc.alias}).
+					Return(nil, tc.mockError).Once()
+			} else {
+				urlService.On("GetURL", mock.Anything, services.GetURLInput{Alias: tc.alias}).
+					Return(&entity.Url{Id: 1, Alias: tc.alias, Url: tc.url, RedirectCode: tc.redirectCode, MaxClicks: tc.maxClicks}, nil).Once()
+				clickService.On("RecordClick", mock.Anything, mock.MatchedBy(func(input services.RecordClickInput) bool {
+					return input.UrlId == 1 && input.IP == "127.0.0.1"
+				})).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, clickService, aliaspolicy.Default(), InactivePage{}, 24*time.Hour, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			httpResp := sendWithoutRedirect(t, http.MethodGet, ts.URL+"/api/v1/urls/"+tc.alias)
+			require.Equal(t, tc.statusCode, httpResp.StatusCode)
+
+			if tc.respError == "" {
+				require.Equal(t, tc.url, httpResp.Header.Get("Location"))
+				require.Equal(t, tc.cacheControl, httpResp.Header.Get("Cache-Control"))
+				return
+			}
+
+			var resp response.Response
+			body, err := io.ReadAll(httpResp.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &resp))
+			require.Equal(t, response.StatusError, resp.Status)
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestRedirectCacheControl(t *testing.T) {
+	permanent := &entity.Url{RedirectCode: http.StatusMovedPermanently}
+
+	require.Equal(t, "no-store", redirectCacheControl(permanent, 0), "redirects aren't cached by default")
+	require.Equal(t, "public, max-age=300", redirectCacheControl(permanent, 5*time.Minute))
+	require.Equal(t, "no-store", redirectCacheControl(&entity.Url{RedirectCode: http.StatusFound}, 5*time.Minute))
+}
+
+func TestRedirectHandlerPrefixURL(t *testing.T) {
+	urlService := mocks.NewUrlService(t)
+	clickService := mocks.NewClickService(t)
+
+	urlService.On("GetURL", mock.Anything, services.GetURLInput{Alias: "docs", Path: "guide/intro.html"}).
+		Return(&entity.Url{
+			Id:            1,
+			Alias:         "docs",
+			Url:           "https://example.com/docs/?v=1",
+			RedirectCode:  http.StatusFound,
+			Prefix:        true,
+			ForwardQuery:  true,
+			QueryConflict: entity.QueryConflictOverride,
+		}, nil).Once()
+	clickService.On("RecordClick", mock.Anything, mock.Anything).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Use(chiMiddleware.URLFormat)
+	r.Get("/api/v1/urls/{alias}/*", NewUrlHandler(urlService, clickService, aliaspolicy.Default(), InactivePage{}, 0, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	httpResp := sendWithoutRedirect(t, http.MethodGet, ts.URL+"/api/v1/urls/docs/guide/intro.html?v=2&utm_source=x")
+	require.Equal(t, http.StatusFound, httpResp.StatusCode)
+	require.Equal(t, "https://example.com/docs/guide/intro.html?utm_source=x&v=2", httpResp.Header.Get("Location"))
+
+	httpResp = sendWithoutRedirect(t, http.MethodGet, ts.URL+"/api/v1/urls/docs/../admin")
+	require.Equal(t, http.StatusBadRequest, httpResp.StatusCode)
+}
+
+func sendWithoutRedirect(t *testing.T, method string, url string) *http.Response {
+	t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+
+	httpResp, err := client.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = httpResp.Body.Close() })
+
+	return httpResp
+}
+
+func TestUpdateHandler(t *testing.T) {
+	newURL := "https://www.google.com/"
+	maxClicks := 10
+
+	tests := []struct {
+		name      string
+		body      string
+		input     services.UpdateURLInput
+		status    string
+		respError string
+		mockError error
+	}{
+		{
+			name:   "update url",
+			body:   `{"url": "https://www.google.com/"}`,
+			input:  services.UpdateURLInput{URL: &newURL},
+			status: response.StatusOK,
+		},
+		{
+			name:   "set max clicks",
+			body:   `{"max_clicks": 10}`,
+			input:  services.UpdateURLInput{MaxClicks: &maxClicks},
+			status: response.StatusOK,
+		},
+		{
+			name:   "clear expiration and max clicks",
+			body:   `{"expires_at": null, "max_clicks": null}`,
+			input:  services.UpdateURLInput{ClearExpiration: true, ClearMaxClicks: true},
+			status: response.StatusOK,
+		},
+		{
+			name:      "invalid url",
+			body:      `{"url": "not a url"}`,
+			status:    response.StatusError,
+			respError: "field URL is not a valid URL",
+		},
+		{
+			name:      "invalid max clicks",
+			body:      `{"max_clicks": 0}`,
+			status:    response.StatusError,
+			respError: "field MaxClicks must be greater than 0",
+		},
+		{
+			name:      "url not found",
+			body:      `{"url": "https://www.google.com/"}`,
+			input:     services.UpdateURLInput{URL: &newURL},
+			status:    response.StatusError,
+			respError: "url not found",
+			mockError: services.ErrURLNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				input := tc.input
+				input.Alias = "test_alias"
+				input.UserId = 1
+
+				urlService.On("UpdateURL", mock.Anything, input).Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Patch("/api/v1/urls/{alias}", NewUrlHandler(urlService, nil, aliaspolicy.Default(), InactivePage{}, 0, nil).Update(slogdiscard.NewDiscardLogger()))
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			body, err := api.SendRequest(http.MethodPatch, ts.URL+"/api/v1/urls/test_alias", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(body, &resp))
+			require.Equal(t, tc.status, resp.Status)
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		alias     string
+		status    string
+		respError string
+		rule      string
+		mockError error
+	}{
+		{
+			name:   "custom alias",
+			body:   `{"url": "https://www.google.com/", "alias": "my-link_1"}`,
+			alias:  "my-link_1",
+			status: response.StatusOK,
+		},
+		{
+			name:   "generated alias",
+			body:   `{"url": "https://www.google.com/"}`,
+			status: response.StatusOK,
+		},
+		{
+			name:      "alias with slash",
+			body:      `{"url": "https://www.google.com/", "alias": "a/b"}`,
+			status:    response.StatusError,
+			respError: "field Alias contains not allowed characters",
+			rule:      "alias_charset",
+		},
+		{
+			name:      "alias with unicode confusable",
+			body:      `{"url": "https://www.google.com/", "alias": "аpple"}`,
+			status:    response.StatusError,
+			respError: "field Alias contains not allowed characters",
+			rule:      "alias_charset",
+		},
+		{
+			name:      "too short alias",
+			body:      `{"url": "https://www.google.com/", "alias": "ab"}`,
+			status:    response.StatusError,
+			respError: "field Alias must be longer than 3 symbols",
+			rule:      "alias_length",
+		},
+		{
+			name:      "reserved alias",
+			body:      `{"url": "https://www.google.com/", "alias": "Admin"}`,
+			status:    response.StatusError,
+			respError: "field Alias is reserved or contains blocked word",
+			rule:      "alias_reserved",
+		},
+		{
+			name:      "unsupported redirect code",
+			body:      `{"url": "https://www.google.com/", "redirect_code": 303}`,
+			status:    response.StatusError,
+			respError: "field RedirectCode must be one of 301 302 307 308",
+			rule:      "oneof",
+		},
+		{
+			name:      "alias already exists",
+			body:      `{"url": "https://www.google.com/", "alias": "taken"}`,
+			alias:     "taken",
+			status:    response.StatusError,
+			respError: "alias already exists",
+			mockError: services.ErrAliasAlreadyExists,
+		},
+		{
+			name:      "unknown utm template",
+			body:      `{"url": "https://www.google.com/", "utm": {"source": "newsletter"}, "utm_template": "missing"}`,
+			status:    response.StatusError,
+			respError: "utm template not found",
+			mockError: services.ErrUtmTemplateNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+
+			if tc.rule == "" {
+				urlService.On("SaveURL", mock.Anything, mock.MatchedBy(func(input services.SaveURLInput) bool {
+					return input.Alias == tc.alias
+				})).Return("generated", tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(withUserId("1"))
+			r.Post("/api/v1/urls", NewUrlHandler(urlService, nil, aliaspolicy.Default(), InactivePage{}, 0, nil).Save(slogdiscard.NewDiscardLogger()))
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			body, err := api.SendRequest(http.MethodPost, ts.URL+"/api/v1/urls", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(body, &resp))
+			require.Equal(t, tc.status, resp.Status)
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.rule != "" {
+				require.Len(t, resp.Errors, 1)
+				require.Equal(t, tc.rule, resp.Errors[0].Rule)
+			}
+		})
+	}
+}
+
+func withUserId(userId string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), middleware.UserCtx, userId)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
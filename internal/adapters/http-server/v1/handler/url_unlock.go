@@ -0,0 +1,128 @@
+package handler
+
+import (
+	_ "embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// unlockCookieName is a name of the cookie with unlock token. The cookie path is the path of the link,
+// so every link has its own token.
+const unlockCookieName = "url_unlock"
+
+//go:embed templates/unlock.html
+var unlockFormHTML string
+
+var unlockForm = template.Must(template.New("unlock").Parse(unlockFormHTML))
+
+func renderUnlockForm(w http.ResponseWriter, status int, alias string, errMsg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	_ = unlockForm.Execute(w, struct {
+		Alias string
+		Error string
+	}{
+		Alias: alias,
+		Error: errMsg,
+	})
+}
+
+// Unlock checks the password submitted with the unlock form. On success it sets the unlock cookie
+// and redirects back to the link, otherwise the form is shown again. The form of the preview page
+// is posted to the preview path, so the alias is taken the same way as for previews.
+func (h *UrlHandler) Unlock(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.Unlock"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias, preview := previewAlias(r)
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		output, err := h.urlService.UnlockURL(r.Context(), services.UnlockURLInput{
+			Alias:    alias,
+			Password: r.PostFormValue("password"),
+			IP:       clientIP(r),
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrWrongURLPassword) {
+				log.Info("wrong url password", slog.String("alias", alias))
+
+				renderUnlockForm(w, http.StatusUnauthorized, alias, "Wrong password")
+				return
+			}
+			if errors.Is(err, services.ErrTooManyUnlockAttempts) {
+				log.Warn("too many unlock attempts", slog.String("alias", alias), slog.String("ip", clientIP(r)))
+
+				renderUnlockForm(w, http.StatusTooManyRequests, alias, "Too many attempts, try again later")
+				return
+			}
+			if errors.Is(err, services.ErrURLNotFound) {
+				log.Info("url not found", slog.String("alias", alias))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("url not found"))
+				return
+			}
+			if errors.Is(err, services.ErrURLExpired) {
+				log.Info("url expired", slog.String("alias", alias))
+
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error("url expired"))
+				return
+			}
+
+			log.Error("failed to unlock url", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("url unlocked", slog.String("alias", alias))
+
+		// the cookie isn't sent to the path with preview suffix, so the preview is shown again
+		// with the query param after unlock
+		path := aliasPath(r)
+		canonical := strings.TrimSuffix(path, previewSuffix)
+		back := canonical + strings.TrimPrefix(r.URL.Path, path)
+		if preview {
+			query := r.URL.Query()
+			query.Set(previewParam, "1")
+			back += "?" + query.Encode()
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:  unlockCookieName,
+			Value: output.Token,
+			// the cookie is set for the alias path, so it works for every path of prefix urls too
+			Path:     canonical,
+			Expires:  output.ExpiresAt,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		http.Redirect(w, r, back, http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/middleware"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/4aykovski/url_shortener/pkg/ratelimit"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlockHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		mockError  error
+		statusCode int
+		formError  string
+	}{
+		{
+			name:       "correct password",
+			password:   "secret",
+			statusCode: http.StatusSeeOther,
+		},
+		{
+			name:       "wrong password",
+			password:   "wrong",
+			mockError:  services.ErrWrongURLPassword,
+			statusCode: http.StatusUnauthorized,
+			formError:  "Wrong password",
+		},
+		{
+			name:       "too many attempts",
+			password:   "wrong",
+			mockError:  services.ErrTooManyUnlockAttempts,
+			statusCode: http.StatusTooManyRequests,
+			formError:  "Too many attempts, try again later",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+			urlService.On("UnlockURL", mock.Anything, services.UnlockURLInput{
+				Alias:    "secret_doc",
+				Password: tc.password,
+				IP:       "127.0.0.1",
+			}).Return(services.UnlockURLOutput{
+				Token:     "token",
+				ExpiresAt: time.Now().Add(time.Minute),
+			}, tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/urls/{alias}", NewUrlHandler(urlService, nil, aliaspolicy.Default(), InactivePage{}, 0, nil).Unlock(slogdiscard.NewDiscardLogger()))
+
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			client := &http.Client{
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}
+
+			httpResp, err := client.PostForm(ts.URL+"/api/v1/urls/secret_doc", url.Values{"password": {tc.password}})
+			require.NoError(t, err)
+			defer httpResp.Body.Close()
+
+			require.Equal(t, tc.statusCode, httpResp.StatusCode)
+
+			if tc.formError == "" {
+				require.Equal(t, "/api/v1/urls/secret_doc", httpResp.Header.Get("Location"))
+
+				cookies := httpResp.Cookies()
+				require.Len(t, cookies, 1)
+				require.Equal(t, unlockCookieName, cookies[0].Name)
+				require.Equal(t, "token", cookies[0].Value)
+				require.Equal(t, "/api/v1/urls/secret_doc", cookies[0].Path)
+				require.True(t, cookies[0].HttpOnly)
+				return
+			}
+
+			body, err := io.ReadAll(httpResp.Body)
+			require.NoError(t, err)
+			require.Contains(t, httpResp.Header.Get("Content-Type"), "text/html")
+			require.Contains(t, string(body), tc.formError)
+		})
+	}
+}
+
+func TestRedirectHandlerProtectedURL(t *testing.T) {
+	urlService := mocks.NewUrlService(t)
+	urlService.On("GetURL", mock.Anything, services.GetURLInput{Alias: "secret_doc"}).
+		Return(nil, services.ErrURLPasswordRequired).Once()
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, nil, aliaspolicy.Default(), InactivePage{}, 0, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	httpResp := sendWithoutRedirect(t, http.MethodGet, ts.URL+"/api/v1/urls/secret_doc")
+	require.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+
+	body, err := io.ReadAll(httpResp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `name="password"`)
+}
+
+func TestUnlockHandlerPreview(t *testing.T) {
+	urlService := mocks.NewUrlService(t)
+	urlService.On("PreviewURL", mock.Anything, services.GetURLInput{Alias: "secret_doc"}).
+		Return(nil, services.ErrURLPasswordRequired).Once()
+	urlService.On("UnlockURL", mock.Anything, services.UnlockURLInput{
+		Alias:    "secret_doc",
+		Password: "secret",
+		IP:       "127.0.0.1",
+	}).Return(services.UnlockURLOutput{
+		Token:     "token",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}, nil).Once()
+
+	h := NewUrlHandler(urlService, nil, aliaspolicy.Default(), InactivePage{}, 0, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/urls/{alias}", h.Redirect(slogdiscard.NewDiscardLogger()))
+	r.Post("/api/v1/urls/{alias}", h.Unlock(slogdiscard.NewDiscardLogger()))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	httpResp := sendWithoutRedirect(t, http.MethodGet, ts.URL+"/api/v1/urls/secret_doc+")
+	require.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+
+	body, err := io.ReadAll(httpResp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `name="password"`)
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// the form has no action, so it's posted to the preview path
+	httpResp, err = client.PostForm(ts.URL+"/api/v1/urls/secret_doc+", url.Values{"password": {"secret"}})
+	require.NoError(t, err)
+	defer httpResp.Body.Close()
+
+	require.Equal(t, http.StatusSeeOther, httpResp.StatusCode)
+	require.Equal(t, "/api/v1/urls/secret_doc?preview=1", httpResp.Header.Get("Location"))
+
+	cookies := httpResp.Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, "/api/v1/urls/secret_doc", cookies[0].Path)
+}
+
+func TestUnlockHandlerForwardedForRotation(t *testing.T) {
+	limiter := ratelimit.NewFailureLimiter(2, time.Minute)
+
+	urlService := mocks.NewUrlService(t)
+	urlService.On("UnlockURL", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, input services.UnlockURLInput) (services.UnlockURLOutput, error) {
+			if !limiter.Attempt(input.Alias + "|" + input.IP) {
+				return services.UnlockURLOutput{}, services.ErrTooManyUnlockAttempts
+			}
+			return services.UnlockURLOutput{}, services.ErrWrongURLPassword
+		})
+
+	r := chi.NewRouter()
+	r.Use(middleware.RealIP(nil))
+	r.Post("/api/v1/urls/{alias}", NewUrlHandler(urlService, nil, aliaspolicy.Default(), InactivePage{}, 0, nil).Unlock(slogdiscard.NewDiscardLogger()))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	statuses := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/urls/secret_doc", strings.NewReader(url.Values{"password": {"wrong"}}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+		req.Header.Set("X-Real-IP", fmt.Sprintf("198.51.100.%d", i))
+
+		httpResp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		httpResp.Body.Close()
+
+		statuses = append(statuses, httpResp.StatusCode)
+	}
+
+	require.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, statuses)
+}
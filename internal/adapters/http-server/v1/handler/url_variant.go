@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+// variantCookieName is a name of the cookie with the variant assigned to the visitor of split-testing url.
+// Like the unlock cookie, its path is the path of the link.
+const variantCookieName = "url_variant"
+
+// variantCookieMaxAge is how long the visitor sticks to the assigned variant.
+const variantCookieMaxAge = 90 * 24 * time.Hour
+
+type UrlVariantInput struct {
+	URL string `json:"url" validate:"required,url"`
+	// Weight is a relative share of visitors, e.g. weights 70 and 30 split visitors 70/30
+	Weight int `json:"weight" validate:"required,gt=0"`
+}
+
+// UrlVariantsInput replaces all variants of the url, empty variants stop splitting it.
+type UrlVariantsInput struct {
+	Variants []UrlVariantInput `json:"variants" validate:"omitempty,min=2,max=10,dive"`
+}
+
+func variantsInput(variants []UrlVariantInput) []services.UrlVariantInput {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	res := make([]services.UrlVariantInput, 0, len(variants))
+	for _, variant := range variants {
+		res = append(res, services.UrlVariantInput{
+			URL:    variant.URL,
+			Weight: variant.Weight,
+		})
+	}
+	return res
+}
+
+func (h *UrlHandler) SetVariants(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.url.SetVariants"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("empty alias")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		var req UrlVariantsInput
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		log.Info("request body decoded", slog.Int("variants", len(req.Variants)))
+
+		if err = h.validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		err = h.urlService.SetURLVariants(r.Context(), services.SetURLVariantsInput{
+			Alias:    alias,
+			UserId:   userId,
+			Variants: variantsInput(req.Variants),
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrURLNotFound) {
+				log.Info("url not found", "alias", alias)
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("url not found"))
+				return
+			}
+			if errors.Is(err, services.ErrInvalidURLVariants) {
+				log.Info("invalid url variants", slogHelper.Err(err))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid url variants"))
+				return
+			}
+
+			log.Error("failed to set url variants", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("url variants set", "alias", alias, slog.Int("variants", len(req.Variants)))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// destinationBase returns the destination for the client before path and query are applied: destination of
+// the first matching targeting rule, the variant assigned to the visitor of split url or the url itself.
+// The variant id is 0 unless the variant is used.
+func (h *UrlHandler) destinationBase(w http.ResponseWriter, r *http.Request, url *entity.Url, log *slog.Logger) (string, int) {
+	if rule := url.MatchRule(h.client(r, url, log)); rule != nil {
+		return rule.Url, 0
+	}
+
+	if !url.IsSplit() {
+		return url.Url, 0
+	}
+
+	variant := stickyVariant(w, r, url)
+	return variant.Url, variant.Id
+}
+
+// stickyVariant returns the variant assigned to the visitor by cookie. New visitors and visitors of
+// removed variants get a random variant, which is remembered in the cookie.
+func stickyVariant(w http.ResponseWriter, r *http.Request, url *entity.Url) *entity.UrlVariant {
+	if cookie, err := r.Cookie(variantCookieName); err == nil {
+		if id, err := strconv.Atoi(cookie.Value); err == nil {
+			if variant := url.Variant(id); variant != nil {
+				return variant
+			}
+		}
+	}
+
+	variant := url.PickVariant(rand.IntN(url.TotalWeight()))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookieName,
+		Value:    strconv.Itoa(variant.Id),
+		Path:     aliasPath(r),
+		MaxAge:   int(variantCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return variant
+}
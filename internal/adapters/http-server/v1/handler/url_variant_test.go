@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler/mocks"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandlerSplitURL(t *testing.T) {
+	variants := map[string]int{
+		"https://example.com/a": 1,
+		"https://example.com/b": 2,
+	}
+
+	tests := []struct {
+		name   string
+		cookie string
+		// location is empty if the variant is picked randomly
+		location  string
+		newCookie bool
+	}{
+		{name: "new visitor", newCookie: true},
+		{name: "returning visitor", cookie: "2", location: "https://example.com/b"},
+		{name: "removed variant", cookie: "3", newCookie: true},
+		{name: "invalid cookie", cookie: "b", newCookie: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlService := mocks.NewUrlService(t)
+			clickService := mocks.NewClickService(t)
+
+			urlService.On("GetURL", mock.Anything, mock.Anything).
+				Return(&entity.Url{
+					Id:           1,
+					Alias:        "ab",
+					Url:          "https://example.com/a",
+					RedirectCode: http.StatusFound,
+					Variants: []entity.UrlVariant{
+						{Id: 1, UrlId: 1, Url: "https://example.com/a", Weight: 70},
+						{Id: 2, UrlId: 1, Url: "https://example.com/b", Weight: 30},
+					},
+				}, nil).Once()
+
+			var click services.RecordClickInput
+			clickService.On("RecordClick", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { click = args.Get(1).(services.RecordClickInput) }).
+				Return(nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/urls/{alias}", NewUrlHandler(urlService, clickService, aliaspolicy.Default(), InactivePage{}, 0, nil).Redirect(slogdiscard.NewDiscardLogger()))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/urls/ab", nil)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: variantCookieName, Value: tc.cookie})
+			}
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			location := rr.Header().Get("Location")
+			if tc.location != "" {
+				require.Equal(t, tc.location, location)
+			}
+			require.Contains(t, variants, location)
+			require.Equal(t, variants[location], click.VariantId, "click is recorded for the served variant")
+
+			cookies := rr.Result().Cookies()
+			if !tc.newCookie {
+				require.Empty(t, cookies)
+				return
+			}
+
+			require.Len(t, cookies, 1)
+			require.Equal(t, variantCookieName, cookies[0].Name)
+			require.Equal(t, "/api/v1/urls/ab", cookies[0].Path)
+			require.Equal(t, strconv.Itoa(variants[location]), cookies[0].Value)
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/internal/services"
+	resp "github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+	"github.com/4aykovski/url_shortener/pkg/utm"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type utmTemplateService interface {
+	CreateUtmTemplate(ctx context.Context, input services.SaveUtmTemplateInput) (*entity.UtmTemplate, error)
+	GetUtmTemplates(ctx context.Context, input services.GetUtmTemplatesInput) ([]entity.UtmTemplate, error)
+	UpdateUtmTemplate(ctx context.Context, input services.SaveUtmTemplateInput) (*entity.UtmTemplate, error)
+	DeleteUtmTemplate(ctx context.Context, input services.DeleteUtmTemplateInput) error
+}
+
+type UtmTemplateHandler struct {
+	utmTemplateService utmTemplateService
+}
+
+func NewUtmTemplateHandler(utmTemplateService utmTemplateService) *UtmTemplateHandler {
+	return &UtmTemplateHandler{
+		utmTemplateService: utmTemplateService,
+	}
+}
+
+// UtmInput is a set of UTM params, it's used both by links and templates.
+type UtmInput struct {
+	Source   string `json:"source,omitempty" validate:"max=256"`
+	Medium   string `json:"medium,omitempty" validate:"max=256"`
+	Campaign string `json:"campaign,omitempty" validate:"max=256"`
+	Term     string `json:"term,omitempty" validate:"max=256"`
+	Content  string `json:"content,omitempty" validate:"max=256"`
+}
+
+func (in *UtmInput) params() utm.Params {
+	if in == nil {
+		return utm.Params{}
+	}
+
+	return utm.Params{
+		Source:   in.Source,
+		Medium:   in.Medium,
+		Campaign: in.Campaign,
+		Term:     in.Term,
+		Content:  in.Content,
+	}
+}
+
+type UtmTemplateInput struct {
+	Name string `json:"name" validate:"required,max=64"`
+	UtmInput
+}
+
+type utmTemplateResponse struct {
+	Name      string    `json:"name"`
+	Source    string    `json:"source,omitempty"`
+	Medium    string    `json:"medium,omitempty"`
+	Campaign  string    `json:"campaign,omitempty"`
+	Term      string    `json:"term,omitempty"`
+	Content   string    `json:"content,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newUtmTemplateResponse(template *entity.UtmTemplate) utmTemplateResponse {
+	return utmTemplateResponse{
+		Name:      template.Name,
+		Source:    template.Source,
+		Medium:    template.Medium,
+		Campaign:  template.Campaign,
+		Term:      template.Term,
+		Content:   template.Content,
+		CreatedAt: template.CreatedAt,
+	}
+}
+
+type UtmTemplateResponse struct {
+	resp.Response
+	Template utmTemplateResponse `json:"template"`
+}
+
+type UtmTemplatesResponse struct {
+	resp.Response
+	Templates []utmTemplateResponse `json:"templates"`
+}
+
+func (h *UtmTemplateHandler) Create(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.utm_template.Create"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		var req UtmTemplateInput
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		template, err := h.utmTemplateService.CreateUtmTemplate(r.Context(), services.SaveUtmTemplateInput{
+			UserId: userId,
+			Name:   req.Name,
+			Params: req.params(),
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrUtmTemplateExists) {
+				log.Info("utm template already exists", slog.String("name", req.Name))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("utm template already exists"))
+				return
+			}
+			if errors.Is(err, services.ErrEmptyUtmTemplate) {
+				log.Info("empty utm template", slog.String("name", req.Name))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("utm template has no params"))
+				return
+			}
+
+			log.Error("failed to create utm template", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("utm template created", slog.String("name", req.Name))
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, UtmTemplateResponse{
+			Response: resp.OK(),
+			Template: newUtmTemplateResponse(template),
+		})
+	}
+}
+
+func (h *UtmTemplateHandler) GetAll(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.utm_template.GetAll"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		templates, err := h.utmTemplateService.GetUtmTemplates(r.Context(), services.GetUtmTemplatesInput{
+			UserId: userId,
+		})
+		if err != nil {
+			log.Error("failed to get utm templates", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		res := make([]utmTemplateResponse, 0, len(templates))
+		for i := range templates {
+			res = append(res, newUtmTemplateResponse(&templates[i]))
+		}
+
+		log.Info("utm templates fetched")
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, UtmTemplatesResponse{
+			Response:  resp.OK(),
+			Templates: res,
+		})
+	}
+}
+
+// Update replaces params of the template named in the path.
+func (h *UtmTemplateHandler) Update(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.utm_template.Update"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			log.Info("empty template name")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		var req UtmInput
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.DecodeError())
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Error("invalid request", slogHelper.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		template, err := h.utmTemplateService.UpdateUtmTemplate(r.Context(), services.SaveUtmTemplateInput{
+			UserId: userId,
+			Name:   name,
+			Params: req.params(),
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrUtmTemplateNotFound) {
+				log.Info("utm template not found", slog.String("name", name))
+
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("utm template not found"))
+				return
+			}
+			if errors.Is(err, services.ErrEmptyUtmTemplate) {
+				log.Info("empty utm template", slog.String("name", name))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("utm template has no params"))
+				return
+			}
+
+			log.Error("failed to update utm template", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("utm template updated", slog.String("name", name))
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, UtmTemplateResponse{
+			Response: resp.OK(),
+			Template: newUtmTemplateResponse(template),
+		})
+	}
+}
+
+func (h *UtmTemplateHandler) Delete(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "v1.handler.utm_template.Delete"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userId, ok := getUserId(r.Context())
+		if !ok {
+			log.Error("failed to get user id")
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			log.Info("empty template name")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.InvalidRequestError())
+			return
+		}
+
+		err := h.utmTemplateService.DeleteUtmTemplate(r.Context(), services.DeleteUtmTemplateInput{
+			UserId: userId,
+			Name:   name,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrUtmTemplateNotFound) {
+				log.Info("utm template not found", slog.String("name", name))
+
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("utm template not found"))
+				return
+			}
+
+			log.Error("failed to delete utm template", slogHelper.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.InternalError())
+			return
+		}
+
+		log.Info("utm template deleted", slog.String("name", name))
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, resp.OK())
+	}
+}
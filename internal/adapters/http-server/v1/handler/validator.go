@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/go-playground/validator/v10"
+)
+
+// newValidator creates validator that knows alias policy tags:
+// alias_length is an alias of min and max tags with policy bounds, alias_charset and alias_reserved are custom ones.
+func newValidator(policy *aliaspolicy.Policy) *validator.Validate {
+	validate := validator.New()
+
+	validate.RegisterAlias("alias_length", fmt.Sprintf("min=%d,max=%d", policy.MinLength(), policy.MaxLength()))
+
+	// registration fails only on empty tag or nil func
+	_ = validate.RegisterValidation("alias_charset", func(fl validator.FieldLevel) bool {
+		return policy.ValidCharset(fl.Field().String())
+	})
+	_ = validate.RegisterValidation("alias_reserved", func(fl validator.FieldLevel) bool {
+		return !policy.IsReserved(fl.Field().String())
+	})
+
+	return validate
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/4aykovski/url_shortener/pkg/api/response"
+	"github.com/go-chi/render"
+)
+
+// AdminOnly lets only admins through. It must go after JWTAuthorization.
+func (m *CustomMiddlewares) AdminOnly(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, _ := r.Context().Value(UserCtx).(string)
+
+			userId, err := strconv.Atoi(id)
+			if err != nil {
+				log.Error("invalid user id in context", slog.String("user_id", id))
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.UnauthorizedError())
+				return
+			}
+
+			isAdmin, err := m.admins.IsAdmin(r.Context(), userId)
+			if err != nil {
+				log.Error("failed to check user role", slog.String("error", err.Error()))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.InternalError())
+				return
+			}
+
+			if !isAdmin {
+				log.Info("user isn't admin", slog.Int("user_id", userId))
+
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("forbidden"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
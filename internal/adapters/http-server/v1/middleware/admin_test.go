@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/require"
+)
+
+type adminsFunc func(ctx context.Context, userId int) (bool, error)
+
+func (f adminsFunc) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	return f(ctx, userId)
+}
+
+func TestAdminOnly(t *testing.T) {
+	tests := []struct {
+		name       string
+		userId     string
+		isAdmin    bool
+		err        error
+		respStatus int
+	}{
+		{name: "admin", userId: "1", isAdmin: true, respStatus: http.StatusOK},
+		{name: "not admin", userId: "2", respStatus: http.StatusForbidden},
+		{name: "no user", userId: "", respStatus: http.StatusUnauthorized},
+		{name: "unexpected error", userId: "1", err: errors.New("unexpected error"), respStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := New(nil, nil, adminsFunc(func(ctx context.Context, userId int) (bool, error) {
+				return tc.isAdmin, tc.err
+			}))
+
+			h := m.AdminOnly(slogdiscard.NewDiscardLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.userId != "" {
+				req = req.WithContext(context.WithValue(req.Context(), UserCtx, tc.userId))
+			}
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respStatus, rr.Code)
+		})
+	}
+}
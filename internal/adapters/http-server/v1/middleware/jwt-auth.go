@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/4aykovski/url_shortener/pkg/api/response"
 	"github.com/go-chi/render"
@@ -44,7 +45,7 @@ func (m *CustomMiddlewares) JWTAuthorization(log *slog.Logger) func(next http.Ha
 
 			log.Debug("auth header parsed - claims", slog.Any("claims", claims))
 
-			id, ok := claims["user_id"]
+			id, ok := claims["user_id"].(string)
 			if !ok {
 				log.Error("invalid jwt claims in auth header")
 
@@ -53,9 +54,17 @@ func (m *CustomMiddlewares) JWTAuthorization(log *slog.Logger) func(next http.Ha
 				return
 			}
 
-			log.Debug("user_id", slog.String("user_id", id.(string)))
+			log.Debug("user_id", slog.String("user_id", id))
 
-			ctx := context.WithValue(r.Context(), UserCtx, id.(string))
+			if m.isRevoked(claims, id) {
+				log.Info("access token is revoked", slog.String("user_id", id))
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.UnauthorizedError())
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserCtx, id)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -85,3 +94,16 @@ func (m *CustomMiddlewares) parseAuthHeader(r *http.Request) (jwt.MapClaims, err
 
 	return claims, err
 }
+
+// isRevoked checks the token against revocations. Tokens issued before jti and iat were added
+// have neither, they're revoked only with all tokens of the user.
+func (m *CustomMiddlewares) isRevoked(claims jwt.MapClaims, userId string) bool {
+	jti, _ := claims["jti"].(string)
+
+	var issuedAt time.Time
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		issuedAt = iat.Time
+	}
+
+	return m.revocations.IsTokenRevoked(jti, userId, issuedAt)
+}
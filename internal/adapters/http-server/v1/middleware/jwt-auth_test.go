@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	tokenManager "github.com/4aykovski/url_shortener/pkg/manager/token"
+	"github.com/4aykovski/url_shortener/pkg/revocation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuthorizationRevokedTokens(t *testing.T) {
+	tM := tokenManager.NewManager("secret")
+	store := revocation.NewStore()
+	m := New(tM, revocationStore{store}, nil)
+
+	h := m.JWTAuthorization(slogdiscard.NewDiscardLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "1", r.Context().Value(UserCtx))
+	}))
+
+	send := func(accessToken string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(authorizationHeader, "Bearer "+accessToken)
+		rr := httptest.NewRecorder()
+
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	first, err := tM.CreateTokensPair("1", time.Minute)
+	require.NoError(t, err)
+	second, err := tM.CreateTokensPair("1", time.Minute)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, send(first.AccessToken))
+
+	claims, err := tM.Parse(first.AccessToken)
+	require.NoError(t, err)
+	store.RevokeToken(claims["jti"].(string), time.Now().Add(time.Minute))
+
+	require.Equal(t, http.StatusUnauthorized, send(first.AccessToken))
+	require.Equal(t, http.StatusOK, send(second.AccessToken), "other tokens of the user are valid")
+
+	store.RevokeUser("1", time.Now(), time.Now().Add(time.Minute))
+
+	require.Equal(t, http.StatusUnauthorized, send(second.AccessToken), "all tokens of the user are revoked")
+}
+
+type revocationStore struct {
+	store *revocation.Store
+}
+
+func (s revocationStore) IsTokenRevoked(jti string, userId string, issuedAt time.Time) bool {
+	return s.store.IsRevoked(jti, userId, issuedAt)
+}
@@ -1,11 +1,30 @@
 package middleware
 
-import tokenManager "github.com/4aykovski/url_shortener/pkg/manager/token"
+import (
+	"context"
+	"time"
+
+	tokenManager "github.com/4aykovski/url_shortener/pkg/manager/token"
+)
+
+type tokenRevocations interface {
+	IsTokenRevoked(jti string, userId string, issuedAt time.Time) bool
+}
+
+type admins interface {
+	IsAdmin(ctx context.Context, userId int) (bool, error)
+}
 
 type CustomMiddlewares struct {
 	tokenManager tokenManager.TokenManager
+	revocations  tokenRevocations
+	admins       admins
 }
 
-func New(tokenManager tokenManager.TokenManager) *CustomMiddlewares {
-	return &CustomMiddlewares{tokenManager: tokenManager}
+func New(tokenManager tokenManager.TokenManager, revocations tokenRevocations, admins admins) *CustomMiddlewares {
+	return &CustomMiddlewares{
+		tokenManager: tokenManager,
+		revocations:  revocations,
+		admins:       admins,
+	}
 }
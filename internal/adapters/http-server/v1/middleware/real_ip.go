@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies parses ips and CIDR ranges of trusted proxies.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	const op = "middleware.ParseTrustedProxies"
+
+	res := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			res = append(res, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		res = append(res, prefix.Masked())
+	}
+
+	return res, nil
+}
+
+// RealIP sets RemoteAddr to the client ip from X-Forwarded-For or X-Real-IP headers like chi RealIP does,
+// but only for requests coming from trusted proxies. Other clients can put anything into these headers,
+// so they would get around limits keyed by ip. X-Forwarded-For is read from the right, the first address
+// that isn't a trusted proxy is the client.
+func RealIP(trustedProxies []netip.Prefix) func(next http.Handler) http.Handler {
+	trusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if peer, ok := parseRemoteAddr(r.RemoteAddr); ok && trusted(peer) {
+				if ip, ok := forwardedIP(r, trusted); ok {
+					r.RemoteAddr = ip.String()
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func parseRemoteAddr(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr, true
+}
+
+func forwardedIP(r *http.Request, trusted func(addr netip.Addr) bool) (netip.Addr, bool) {
+	if forwardedFor := r.Header.Values("X-Forwarded-For"); len(forwardedFor) != 0 {
+		hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+
+		var client netip.Addr
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+
+			client = addr.Unmap()
+			if !trusted(client) {
+				break
+			}
+		}
+
+		return client, client.IsValid()
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
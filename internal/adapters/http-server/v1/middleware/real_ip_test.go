@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealIP(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		forwardedFor   string
+		realIP         string
+		wantRemoteAddr string
+	}{
+		{
+			name:           "untrusted client",
+			remoteAddr:     "203.0.113.1:1234",
+			forwardedFor:   "198.51.100.1",
+			realIP:         "198.51.100.2",
+			wantRemoteAddr: "203.0.113.1:1234",
+		},
+		{
+			name:           "trusted proxy",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   "198.51.100.1",
+			wantRemoteAddr: "198.51.100.1",
+		},
+		{
+			name:           "spoofed hop before trusted proxies",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   "1.1.1.1, 198.51.100.1, 192.168.1.1",
+			wantRemoteAddr: "198.51.100.1",
+		},
+		{
+			name:           "real ip from trusted proxy",
+			remoteAddr:     "10.0.0.1:1234",
+			realIP:         "198.51.100.2",
+			wantRemoteAddr: "198.51.100.2",
+		},
+		{
+			name:           "invalid forwarded ip",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   "unknown",
+			wantRemoteAddr: "10.0.0.1:1234",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var remoteAddr string
+			h := RealIP(trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				remoteAddr = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+			if tc.realIP != "" {
+				req.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, tc.wantRemoteAddr, remoteAddr)
+		})
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"not an ip"})
+	require.Error(t, err)
+}
@@ -3,10 +3,18 @@ package v1
 import (
 	"context"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"time"
 
 	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler"
 	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/middleware"
+	"github.com/4aykovski/url_shortener/internal/entity"
 	"github.com/4aykovski/url_shortener/internal/services"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/geoip"
 	tokenManager "github.com/4aykovski/url_shortener/pkg/manager/token"
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
@@ -15,52 +23,139 @@ import (
 type authService interface {
 	SignUp(ctx context.Context, input services.AuthSignUpInput) error
 	SignIn(ctx context.Context, input services.AuthSignInInput) (*tokenManager.Tokens, error)
-	Logout(ctx context.Context, refreshToken string) error
-	Refresh(ctx context.Context, refreshToken string) (*tokenManager.Tokens, error)
+	Logout(ctx context.Context, input services.AuthLogoutInput) error
+	Refresh(ctx context.Context, input services.AuthRefreshInput) (*tokenManager.Tokens, error)
+	GetSessions(ctx context.Context, userId int) ([]entity.RefreshSession, error)
+	RevokeSession(ctx context.Context, input services.RevokeSessionInput) error
+	RevokeAllSessions(ctx context.Context, userId int) error
+	ChangePassword(ctx context.Context, input services.ChangePasswordInput) error
+	DeleteAccount(ctx context.Context, input services.DeleteAccountInput) error
+	BanUser(ctx context.Context, userId int) error
+	IsAdmin(ctx context.Context, userId int) (bool, error)
+}
+
+type tokenRevocations interface {
+	IsTokenRevoked(jti string, userId string, issuedAt time.Time) bool
 }
 
 type urlService interface {
 	SaveURL(ctx context.Context, input services.SaveURLInput) (string, error)
-	GetURL(ctx context.Context, input services.GetURLInput) (string, error)
+	SaveURLs(ctx context.Context, input services.SaveURLsInput) ([]services.SaveURLResult, error)
+	GetURL(ctx context.Context, input services.GetURLInput) (*entity.Url, error)
+	PreviewURL(ctx context.Context, input services.GetURLInput) (*entity.Url, error)
 	DeleteURL(ctx context.Context, input services.DeleteURLInput) error
+	UpdateURL(ctx context.Context, input services.UpdateURLInput) error
+	GetURLHistory(ctx context.Context, input services.GetURLHistoryInput) ([]entity.UrlVersion, error)
+	RollbackURL(ctx context.Context, input services.RollbackURLInput) error
+	ImportURLs(ctx context.Context, urls []services.SaveURLInput) []services.SaveURLResult
+	ExportUserURLs(ctx context.Context, input services.ExportUserURLsInput, fn func(url *entity.Url) error) error
 	GetAllUserUrls(ctx context.Context, input services.GetAllUserUrlsInput) (services.GetAllUserUrlsOutput, error)
+	UnlockURL(ctx context.Context, input services.UnlockURLInput) (services.UnlockURLOutput, error)
+	GetTargetingRules(ctx context.Context, input services.GetTargetingRulesInput) (*entity.Url, error)
+	SetTargetingRules(ctx context.Context, input services.SetTargetingRulesInput) error
+	SetURLVariants(ctx context.Context, input services.SetURLVariantsInput) error
+}
+
+type utmTemplateService interface {
+	CreateUtmTemplate(ctx context.Context, input services.SaveUtmTemplateInput) (*entity.UtmTemplate, error)
+	GetUtmTemplates(ctx context.Context, input services.GetUtmTemplatesInput) ([]entity.UtmTemplate, error)
+	UpdateUtmTemplate(ctx context.Context, input services.SaveUtmTemplateInput) (*entity.UtmTemplate, error)
+	DeleteUtmTemplate(ctx context.Context, input services.DeleteUtmTemplateInput) error
+}
+
+type geoLocator interface {
+	Lookup(ip net.IP) (geoip.Location, error)
+}
+
+type clickService interface {
+	RecordClick(ctx context.Context, input services.RecordClickInput) error
+	GetURLStats(ctx context.Context, input services.GetURLStatsInput) (*entity.UrlStats, error)
 }
 
 func NewMux(
 	log *slog.Logger,
 	urlService urlService,
+	clickService clickService,
 	authService authService,
+	utmTemplateService utmTemplateService,
 	tokenManager tokenManager.TokenManager,
+	revocations tokenRevocations,
+	aliasPolicy *aliaspolicy.Policy,
+	inactivePage handler.InactivePage,
+	redirectMaxAge time.Duration,
+	geo geoLocator,
+	trustedProxies []netip.Prefix,
 ) *chi.Mux {
 	var (
 		mux               = chi.NewMux()
 		userHandler       = handler.NewAuthHandler(authService, tokenManager)
-		urlHandler        = handler.NewUrlHandler(urlService)
-		customMiddlewares = middleware.New(tokenManager)
+		urlHandler        = handler.NewUrlHandler(urlService, clickService, aliasPolicy, inactivePage, redirectMaxAge, geo)
+		utmHandler        = handler.NewUtmTemplateHandler(utmTemplateService)
+		customMiddlewares = middleware.New(tokenManager, revocations, authService)
 	)
 
 	mux.Use(chiMiddleware.RequestID)
-	mux.Use(chiMiddleware.RealIP)
+	mux.Use(middleware.RealIP(trustedProxies))
 	mux.Use(customMiddlewares.Logger(log))
 	mux.Use(chiMiddleware.Recoverer)
 	mux.Use(chiMiddleware.URLFormat)
 
+	mux.Get("/.well-known/jwks.json", userHandler.JWKS(log))
+
 	mux.Route("/api/v1", func(r chi.Router) {
 		initUrlRoutes(log, r, urlHandler, customMiddlewares)
 		initAuthRoutes(log, r, userHandler, customMiddlewares)
+		initUtmTemplateRoutes(log, r, utmHandler, customMiddlewares)
+		initAdminRoutes(log, r, userHandler, customMiddlewares)
 	})
 
 	return mux
 }
 
+// RouteMethods returns methods of all routes of the router and OPTIONS used by CORS preflight requests,
+// so CORS allows every method the api serves, e.g. DELETE of sessions and account.
+func RouteMethods(r chi.Routes) ([]string, error) {
+	seen := map[string]bool{http.MethodOptions: true}
+	err := chi.Walk(r, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		seen[method] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return methods, nil
+}
+
 func initUrlRoutes(log *slog.Logger, r chi.Router, h *handler.UrlHandler, mws *middleware.CustomMiddlewares) {
 	r.Route("/urls", func(r chi.Router) {
 		r.Get("/{alias}", h.Redirect(log))
+		// paths of prefix urls, except stats, history, targeting and variants taken by the routes below
+		r.Get("/{alias}/*", h.Redirect(log))
+		r.Post("/{alias}", h.Unlock(log))
+		r.Post("/{alias}/*", h.Unlock(log))
 		r.Group(func(r chi.Router) {
 			r.Use(mws.JWTAuthorization(log))
 			r.Post("/", h.Save(log))
+			r.Post("/batch", h.SaveBatch(log))
+			r.Post("/import", h.Import(log))
+			r.Get("/export", h.Export(log))
 			r.Get("/", h.GetAllUserUrls(log))
+			r.Patch("/{alias}", h.Update(log))
 			r.Delete("/{alias}", h.Delete(log))
+			r.Get("/{alias}/stats", h.Stats(log))
+			r.Get("/{alias}/history", h.History(log))
+			r.Post("/{alias}/rollback/{version}", h.Rollback(log))
+			r.Get("/{alias}/targeting", h.GetTargeting(log))
+			r.Put("/{alias}/targeting", h.SetTargeting(log))
+			r.Delete("/{alias}/targeting", h.DeleteTargeting(log))
+			r.Put("/{alias}/variants", h.SetVariants(log))
 		})
 	})
 }
@@ -73,5 +168,34 @@ func initAuthRoutes(log *slog.Logger, r chi.Router, h *handler.AuthHandler, mws
 			r.Post("/refresh", h.Refresh(log))
 			r.Post("/logout", h.Logout(log))
 		})
+		r.Route("/sessions", func(r chi.Router) {
+			r.Use(mws.JWTAuthorization(log))
+			r.Get("/", h.Sessions(log))
+			r.Delete("/", h.RevokeAllSessions(log))
+			r.Delete("/{id}", h.RevokeSession(log))
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(mws.JWTAuthorization(log))
+			r.Put("/password", h.ChangePassword(log))
+			r.Delete("/me", h.DeleteAccount(log))
+		})
+	})
+}
+
+func initUtmTemplateRoutes(log *slog.Logger, r chi.Router, h *handler.UtmTemplateHandler, mws *middleware.CustomMiddlewares) {
+	r.Route("/utm-templates", func(r chi.Router) {
+		r.Use(mws.JWTAuthorization(log))
+		r.Post("/", h.Create(log))
+		r.Get("/", h.GetAll(log))
+		r.Put("/{name}", h.Update(log))
+		r.Delete("/{name}", h.Delete(log))
+	})
+}
+
+func initAdminRoutes(log *slog.Logger, r chi.Router, h *handler.AuthHandler, mws *middleware.CustomMiddlewares) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(mws.JWTAuthorization(log))
+		r.Use(mws.AdminOnly(log))
+		r.Post("/users/{id}/ban", h.BanUser(log))
 	})
 }
@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/http-server/v1/handler"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteMethods(t *testing.T) {
+	mux := NewMux(slogdiscard.NewDiscardLogger(), nil, nil, nil, nil, nil, nil, aliaspolicy.Default(), handler.InactivePage{}, 0, nil, nil)
+
+	methods, err := RouteMethods(mux)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		http.MethodDelete,
+		http.MethodGet,
+		http.MethodOptions,
+		http.MethodPatch,
+		http.MethodPost,
+		http.MethodPut,
+	}, methods)
+}
@@ -1,14 +1,35 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrURLNotFound             = errors.New("url not found")
 	ErrUrlExists               = errors.New("url exists")
-	ErrURLsNotFound            = errors.New("urls not found")
+	ErrURLClicksExhausted      = errors.New("url clicks exhausted")
+	ErrURLVersionNotFound      = errors.New("url version not found")
 	ErrUserExists              = errors.New("user exists")
 	ErrUserNotFound            = errors.New("user not found")
 	ErrUsersNotFound           = errors.New("user not found")
 	ErrRefreshSessionNotFound  = errors.New("refresh session not found")
 	ErrRefreshSessionsNotFound = errors.New("refresh sessions not found")
+	ErrRefreshSessionRotated   = errors.New("refresh session rotated")
+	ErrUtmTemplateNotFound     = errors.New("utm template not found")
+	ErrUtmTemplateExists       = errors.New("utm template exists")
 )
+
+// BatchError reports which item of a batch caused the whole batch to fail.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch item %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,28 @@
+package repository
+
+import "time"
+
+type UrlsSortField string
+
+const (
+	SortByCreatedAt UrlsSortField = "created_at"
+	SortByClicks    UrlsSortField = "clicks"
+)
+
+type ListURLsParams struct {
+	UserId int
+	// Search filters urls which alias or destination contains the substring, case-insensitive.
+	Search     string
+	SortBy     UrlsSortField
+	Descending bool
+	Limit      int
+	// After is a keyset cursor, only urls following it in the sort order are returned.
+	After *UrlsCursor
+}
+
+// UrlsCursor is a position in the list of urls. Only the field of the sort order and Id are used.
+type UrlsCursor struct {
+	CreatedAt time.Time
+	Clicks    int
+	Id        int
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/lib/pq"
+)
+
+type ClickRepositoryPostgres struct {
+	postgres *Postgres
+}
+
+func NewClickRepository(postgres *Postgres) *ClickRepositoryPostgres {
+	return &ClickRepositoryPostgres{postgres: postgres}
+}
+
+// SaveClicks inserts clicks with a single multi-row statement.
+// Clicks of urls that were deleted in the meantime are skipped instead of failing the whole batch.
+func (repo *ClickRepositoryPostgres) SaveClicks(ctx context.Context, clicks []entity.Click) error {
+	const op = "database.Postgres.ClickRepository.SaveClicks"
+
+	var (
+		urlIds       = make([]int64, 0, len(clicks))
+		clickedAt    = make([]string, 0, len(clicks))
+		referrers    = make([]string, 0, len(clicks))
+		userAgents   = make([]string, 0, len(clicks))
+		visitorHashs = make([]string, 0, len(clicks))
+		requestIds   = make([]string, 0, len(clicks))
+		variantIds   = make([]int64, 0, len(clicks))
+	)
+	for _, click := range clicks {
+		urlIds = append(urlIds, int64(click.UrlId))
+		clickedAt = append(clickedAt, click.ClickedAt.UTC().Format(timestampLayout))
+		referrers = append(referrers, click.Referrer)
+		userAgents = append(userAgents, click.UserAgent)
+		visitorHashs = append(visitorHashs, click.VisitorHash)
+		requestIds = append(requestIds, click.RequestId)
+		variantIds = append(variantIds, int64(click.VariantId))
+	}
+
+	stmt, err := repo.postgres.db.Prepare(`
+		INSERT INTO clicks(url_id, clicked_at, referrer, user_agent, visitor_hash, request_id, variant_id)
+		SELECT c.url_id, c.clicked_at, c.referrer, c.user_agent, c.visitor_hash, c.request_id,
+			(SELECT v.id FROM url_variants v WHERE v.id = c.variant_id)
+		FROM unnest($1::int[], $2::timestamp[], $3::text[], $4::text[], $5::text[], $6::text[], $7::int[])
+			AS c(url_id, clicked_at, referrer, user_agent, visitor_hash, request_id, variant_id)
+		WHERE EXISTS (SELECT 1 FROM urls WHERE urls.id = c.url_id)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		pq.Array(urlIds),
+		pq.Array(clickedAt),
+		pq.Array(referrers),
+		pq.Array(userAgents),
+		pq.Array(visitorHashs),
+		pq.Array(requestIds),
+		pq.Array(variantIds),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (repo *ClickRepositoryPostgres) GetClicksTotal(ctx context.Context, urlId int) (int, int, error) {
+	const op = "database.Postgres.ClickRepository.GetClicksTotal"
+
+	stmt, err := repo.postgres.db.Prepare("SELECT COUNT(*), COUNT(DISTINCT visitor_hash) FROM clicks WHERE url_id = $1")
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var total, unique int
+	err = stmt.QueryRowContext(ctx, urlId).Scan(&total, &unique)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return total, unique, nil
+}
+
+// GetDailyClicks returns clicks grouped by day in [from, to) range. Days without clicks are omitted.
+func (repo *ClickRepositoryPostgres) GetDailyClicks(ctx context.Context, urlId int, from time.Time, to time.Time) ([]entity.DailyClicks, error) {
+	const op = "database.Postgres.ClickRepository.GetDailyClicks"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		SELECT date_trunc('day', clicked_at) AS day, COUNT(*), COUNT(DISTINCT visitor_hash)
+		FROM clicks
+		WHERE url_id = $1 AND clicked_at >= $2 AND clicked_at < $3
+		GROUP BY day
+		ORDER BY day`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, urlId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var daily []entity.DailyClicks
+	for rows.Next() {
+		var day entity.DailyClicks
+		err = rows.Scan(&day.Day, &day.Clicks, &day.UniqueVisitors)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		daily = append(daily, day)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return daily, nil
+}
+
+// GetVariantClicks returns clicks grouped by variant, including archived variants, in the order variants
+// were added. Clicks without variant are omitted.
+func (repo *ClickRepositoryPostgres) GetVariantClicks(ctx context.Context, urlId int) ([]entity.VariantClicks, error) {
+	const op = "database.Postgres.ClickRepository.GetVariantClicks"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		SELECT v.id, v.url, v.weight, v.archived_at IS NOT NULL, COUNT(*), COUNT(DISTINCT c.visitor_hash)
+		FROM clicks c
+		JOIN url_variants v ON v.id = c.variant_id
+		WHERE c.url_id = $1
+		GROUP BY v.id
+		ORDER BY v.id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, urlId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var variants []entity.VariantClicks
+	for rows.Next() {
+		var variant entity.VariantClicks
+		err = rows.Scan(&variant.VariantId, &variant.Url, &variant.Weight, &variant.Archived, &variant.Clicks, &variant.UniqueVisitors)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variants, nil
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -8,6 +9,9 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// timestampLayout is used to pass timestamps inside arrays, which lib/pq can't encode itself.
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
 type Postgres struct {
 	db *sql.DB
 }
@@ -26,3 +30,18 @@ func New(cfg config.Postgres) (*Postgres, error) {
 
 	return &Postgres{db: db}, nil
 }
+
+// withTx runs fn in a transaction. The transaction is committed if fn returns nil, otherwise it's rolled back.
+func (p *Postgres) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
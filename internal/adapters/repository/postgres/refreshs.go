@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/4aykovski/url_shortener/internal/adapters/repository"
 	"github.com/4aykovski/url_shortener/internal/entity"
@@ -24,18 +25,67 @@ func (repo *RefreshSessionRepositoryPostgres) CreateRefreshSession(ctx context.C
 	const op = "database.Postgres.RefreshSessionRepository.CreateRefreshSession"
 
 	stmt, err := repo.postgres.db.Prepare(`
-		INSERT INTO refresh_sessions(user_id, refresh_token, expires_in) 
-		VALUES($1, $2, $3)`)
+		INSERT INTO refresh_sessions(user_id, refresh_token_hash, expires_in, family_id, user_agent, ip) 
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, last_used_at`)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer stmt.Close()
 
-	_, err = stmt.ExecContext(
+	err = stmt.QueryRowContext(
 		ctx,
 		refreshSession.UserId,
-		refreshSession.RefreshToken,
+		refreshSession.RefreshTokenHash,
 		refreshSession.ExpiresIn,
-	)
+		refreshSession.FamilyId,
+		refreshSession.UserAgent,
+		refreshSession.IP,
+	).Scan(&refreshSession.Id, &refreshSession.CreatedAt, &refreshSession.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RotateRefreshSession marks the session as rotated and creates its child in one transaction.
+// The child keeps creation time of the family. It returns repository.ErrRefreshSessionRotated if the session has been already rotated.
+func (repo *RefreshSessionRepositoryPostgres) RotateRefreshSession(ctx context.Context, sessionId int, child *entity.RefreshSession) error {
+	const op = "database.Postgres.RefreshSessionRepository.RotateRefreshSession"
+
+	err := repo.postgres.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE refresh_sessions SET rotated_at = (now() AT TIME ZONE 'UTC')
+			WHERE id = $1 AND rotated_at IS NULL`, sessionId)
+		if err != nil {
+			return err
+		}
+
+		rotated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rotated == 0 {
+			return repository.ErrRefreshSessionRotated
+		}
+
+		return tx.QueryRowContext(
+			ctx,
+			`INSERT INTO refresh_sessions(user_id, refresh_token_hash, expires_in, family_id, parent_id, user_agent, ip, created_at) 
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, last_used_at`,
+			child.UserId,
+			child.RefreshTokenHash,
+			child.ExpiresIn,
+			child.FamilyId,
+			child.ParentId,
+			child.UserAgent,
+			child.IP,
+			child.CreatedAt,
+		).Scan(&child.Id, &child.LastUsedAt)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -43,15 +93,24 @@ func (repo *RefreshSessionRepositoryPostgres) CreateRefreshSession(ctx context.C
 	return nil
 }
 
-func (repo *RefreshSessionRepositoryPostgres) DeleteRefreshSession(ctx context.Context, token string) error {
+// DeleteRefreshSession deletes the family of the active session with the token hash, rotated ancestors
+// of the session are not needed anymore.
+func (repo *RefreshSessionRepositoryPostgres) DeleteRefreshSession(ctx context.Context, tokenHash string) error {
 	const op = "database.Postgres.RefreshSessionRepository.DeleteRefreshSession"
 
-	stmt, err := repo.postgres.db.Prepare("DELETE FROM refresh_sessions WHERE refresh_token = $1")
+	stmt, err := repo.postgres.db.Prepare(`
+		DELETE FROM refresh_sessions 
+		WHERE family_id = (SELECT family_id FROM refresh_sessions WHERE refresh_token_hash = $1 AND rotated_at IS NULL)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, tokenHash)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	res, err := stmt.ExecContext(ctx, token)
 	deleted, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -61,49 +120,108 @@ func (repo *RefreshSessionRepositoryPostgres) DeleteRefreshSession(ctx context.C
 		return repository.ErrRefreshSessionNotFound
 	}
 
+	return nil
+}
+
+// DeleteUserRefreshSession deletes the family of the active user session with the id.
+func (repo *RefreshSessionRepositoryPostgres) DeleteUserRefreshSession(ctx context.Context, userId int, sessionId int) error {
+	const op = "database.Postgres.RefreshSessionRepository.DeleteUserRefreshSession"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		DELETE FROM refresh_sessions 
+		WHERE family_id = (SELECT family_id FROM refresh_sessions WHERE id = $1 AND user_id = $2 AND rotated_at IS NULL)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, sessionId, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if deleted == 0 {
+		return repository.ErrRefreshSessionNotFound
+	}
+
 	return nil
 }
 
-func (repo *RefreshSessionRepositoryPostgres) UpdateRefreshSession(ctx context.Context, refreshSession *entity.RefreshSession) error {
-	const op = "database.Postgres.RefreshSessionRepository.UpdateRefreshSession"
+// DeleteUserRefreshSessions deletes all sessions of the user.
+func (repo *RefreshSessionRepositoryPostgres) DeleteUserRefreshSessions(ctx context.Context, userId int) error {
+	const op = "database.Postgres.RefreshSessionRepository.DeleteUserRefreshSessions"
 
-	stmt, err := repo.postgres.db.Prepare("UPDATE refresh_sessions SET refresh_token = $1, expires_in = $2 WHERE id = $4")
+	stmt, err := repo.postgres.db.Prepare("DELETE FROM refresh_sessions WHERE user_id = $1")
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer stmt.Close()
 
-	_, err = stmt.ExecContext(
-		ctx,
-		refreshSession.RefreshToken,
-		refreshSession.ExpiresIn,
-		refreshSession.Id,
-	)
+	if _, err = stmt.ExecContext(ctx, userId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteRefreshSessionFamily deletes all sessions of the family.
+func (repo *RefreshSessionRepositoryPostgres) DeleteRefreshSessionFamily(ctx context.Context, familyId string) error {
+	const op = "database.Postgres.RefreshSessionRepository.DeleteRefreshSessionFamily"
+
+	stmt, err := repo.postgres.db.Prepare("DELETE FROM refresh_sessions WHERE family_id = $1")
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, familyId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	return nil
 }
 
-func (repo *RefreshSessionRepositoryPostgres) GetRefreshSession(ctx context.Context, refreshToken string) (*entity.RefreshSession, error) {
+// DeleteExpiredRefreshSessionFamilies deletes families which active session has expired before the time.
+// The active session is the newest one of the family, so rotated sessions of the family have expired too.
+func (repo *RefreshSessionRepositoryPostgres) DeleteExpiredRefreshSessionFamilies(ctx context.Context, before time.Time) (int64, error) {
+	const op = "database.Postgres.RefreshSessionRepository.DeleteExpiredRefreshSessionFamilies"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		DELETE FROM refresh_sessions 
+		WHERE family_id IN (SELECT family_id FROM refresh_sessions WHERE rotated_at IS NULL AND expires_in < $1)`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+func (repo *RefreshSessionRepositoryPostgres) GetRefreshSession(ctx context.Context, refreshTokenHash string) (*entity.RefreshSession, error) {
 	const op = "database.Postgres.RefreshSessionRepository.GetRefreshSession"
 
-	stmt, err := repo.postgres.db.Prepare("SELECT id, user_id, refresh_token, expires_in FROM refresh_sessions WHERE refresh_token = $1")
+	stmt, err := repo.postgres.db.Prepare("SELECT " + refreshSessionColumns + " FROM refresh_sessions WHERE refresh_token_hash = $1")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer stmt.Close()
 
-	var refreshSession entity.RefreshSession
-	err = stmt.QueryRowContext(ctx, refreshToken).Scan(
-		&refreshSession.Id,
-		&refreshSession.UserId,
-		&refreshSession.RefreshToken,
-		&refreshSession.ExpiresIn,
-	)
+	refreshSession, err := scanRefreshSession(stmt.QueryRowContext(ctx, refreshTokenHash))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repository.ErrRefreshSessionNotFound
@@ -112,13 +230,13 @@ func (repo *RefreshSessionRepositoryPostgres) GetRefreshSession(ctx context.Cont
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &refreshSession, nil
+	return refreshSession, nil
 }
 
 func (repo *RefreshSessionRepositoryPostgres) GetUserRefreshSessions(ctx context.Context, userId int) ([]entity.RefreshSession, error) {
 	const op = "database.Postgres.RefreshSessionRepository.GetUserRefreshSessions"
 
-	stmt, err := repo.postgres.db.Prepare("SELECT id, user_id, refresh_token, expires_in FROM refresh_sessions WHERE user_id=$1")
+	stmt, err := repo.postgres.db.Prepare("SELECT " + refreshSessionColumns + " FROM refresh_sessions WHERE user_id=$1 AND rotated_at IS NULL ORDER BY last_used_at DESC")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -132,20 +250,52 @@ func (repo *RefreshSessionRepositoryPostgres) GetUserRefreshSessions(ctx context
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	defer rows.Close()
+
 	var refreshSessions []entity.RefreshSession
 	for rows.Next() {
-		var refreshSession entity.RefreshSession
-		err = rows.Scan(
-			&refreshSession.Id,
-			&refreshSession.UserId,
-			&refreshSession.RefreshToken,
-			&refreshSession.ExpiresIn,
-		)
+		refreshSession, err := scanRefreshSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		refreshSessions = append(refreshSessions, refreshSession)
+		refreshSessions = append(refreshSessions, *refreshSession)
 	}
 
 	return refreshSessions, nil
 }
+
+const refreshSessionColumns = "id, user_id, refresh_token_hash, expires_in, family_id, parent_id, rotated_at, user_agent, ip, created_at, last_used_at"
+
+func scanRefreshSession(row rowScanner) (*entity.RefreshSession, error) {
+	var (
+		refreshSession entity.RefreshSession
+		parentId       sql.NullInt64
+		rotatedAt      sql.NullTime
+	)
+	err := row.Scan(
+		&refreshSession.Id,
+		&refreshSession.UserId,
+		&refreshSession.RefreshTokenHash,
+		&refreshSession.ExpiresIn,
+		&refreshSession.FamilyId,
+		&parentId,
+		&rotatedAt,
+		&refreshSession.UserAgent,
+		&refreshSession.IP,
+		&refreshSession.CreatedAt,
+		&refreshSession.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentId.Valid {
+		id := int(parentId.Int64)
+		refreshSession.ParentId = &id
+	}
+	if rotatedAt.Valid {
+		refreshSession.RotatedAt = &rotatedAt.Time
+	}
+
+	return &refreshSession, nil
+}
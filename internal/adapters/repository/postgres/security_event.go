@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+type SecurityEventRepositoryPostgres struct {
+	postgres *Postgres
+}
+
+func NewSecurityEventRepository(postgres *Postgres) *SecurityEventRepositoryPostgres {
+	return &SecurityEventRepositoryPostgres{
+		postgres: postgres,
+	}
+}
+
+func (repo *SecurityEventRepositoryPostgres) CreateSecurityEvent(ctx context.Context, event *entity.SecurityEvent) error {
+	const op = "database.Postgres.SecurityEventRepository.CreateSecurityEvent"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		INSERT INTO security_events(user_id, type, details) 
+		VALUES($1, $2, $3)
+		RETURNING id, created_at`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(ctx, event.UserId, event.Type, event.Details).Scan(&event.Id, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+type TokenRevocationRepositoryPostgres struct {
+	postgres *Postgres
+}
+
+func NewTokenRevocationRepository(postgres *Postgres) *TokenRevocationRepositoryPostgres {
+	return &TokenRevocationRepositoryPostgres{
+		postgres: postgres,
+	}
+}
+
+func (repo *TokenRevocationRepositoryPostgres) CreateRevokedToken(ctx context.Context, token *entity.RevokedToken) error {
+	const op = "database.Postgres.TokenRevocationRepository.CreateRevokedToken"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		INSERT INTO revoked_tokens(jti, user_id, expires_at)
+		VALUES($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, token.Jti, token.UserId, token.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveUserTokenRevocation creates the revocation or moves the existing one of the user forward.
+func (repo *TokenRevocationRepositoryPostgres) SaveUserTokenRevocation(ctx context.Context, revocation *entity.UserTokenRevocation) error {
+	const op = "database.Postgres.TokenRevocationRepository.SaveUserTokenRevocation"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		INSERT INTO user_token_revocations(user_id, revoked_before, expires_at)
+		VALUES($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			revoked_before = GREATEST(user_token_revocations.revoked_before, EXCLUDED.revoked_before),
+			expires_at = GREATEST(user_token_revocations.expires_at, EXCLUDED.expires_at)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, revocation.UserId, revocation.RevokedBefore.UTC(), revocation.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetRevokedTokens returns revoked tokens that haven't expired yet.
+func (repo *TokenRevocationRepositoryPostgres) GetRevokedTokens(ctx context.Context) ([]entity.RevokedToken, error) {
+	const op = "database.Postgres.TokenRevocationRepository.GetRevokedTokens"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		SELECT jti, user_id, expires_at FROM revoked_tokens
+		WHERE expires_at > (now() AT TIME ZONE 'UTC')`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tokens []entity.RevokedToken
+	for rows.Next() {
+		var token entity.RevokedToken
+		if err = rows.Scan(&token.Jti, &token.UserId, &token.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// GetUserTokenRevocations returns revocations of users that haven't expired yet.
+func (repo *TokenRevocationRepositoryPostgres) GetUserTokenRevocations(ctx context.Context) ([]entity.UserTokenRevocation, error) {
+	const op = "database.Postgres.TokenRevocationRepository.GetUserTokenRevocations"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		SELECT user_id, revoked_before, expires_at FROM user_token_revocations
+		WHERE expires_at > (now() AT TIME ZONE 'UTC')`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revocations []entity.UserTokenRevocation
+	for rows.Next() {
+		var revocation entity.UserTokenRevocation
+		if err = rows.Scan(&revocation.UserId, &revocation.RevokedBefore, &revocation.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revocations = append(revocations, revocation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revocations, nil
+}
+
+// DeleteExpiredTokenRevocations deletes revocations of tokens that have already expired.
+func (repo *TokenRevocationRepositoryPostgres) DeleteExpiredTokenRevocations(ctx context.Context) error {
+	const op = "database.Postgres.TokenRevocationRepository.DeleteExpiredTokenRevocations"
+
+	err := repo.postgres.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= (now() AT TIME ZONE 'UTC')`)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM user_token_revocations WHERE expires_at <= (now() AT TIME ZONE 'UTC')`)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
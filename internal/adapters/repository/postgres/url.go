@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/4aykovski/url_shortener/internal/adapters/repository"
 	"github.com/4aykovski/url_shortener/internal/entity"
@@ -13,54 +15,231 @@ import (
 
 type UrlRepositoryPostgres struct {
 	postgres *Postgres
+	// caseInsensitiveAliases forbids aliases differing from existing ones only in case.
+	caseInsensitiveAliases bool
 }
 
-func NewUrlRepository(pq *Postgres) *UrlRepositoryPostgres {
-	return &UrlRepositoryPostgres{postgres: pq}
+func NewUrlRepository(pq *Postgres, caseInsensitiveAliases bool) *UrlRepositoryPostgres {
+	return &UrlRepositoryPostgres{
+		postgres:               pq,
+		caseInsensitiveAliases: caseInsensitiveAliases,
+	}
 }
 
-func (repo *UrlRepositoryPostgres) SaveURL(ctx context.Context, urlToSave string, alias string, userId int) error {
+func (repo *UrlRepositoryPostgres) SaveURL(ctx context.Context, url *entity.Url) error {
 	const op = "database.Postgres.UrlRepository.SaveURL"
 
-	stmt, err := repo.postgres.db.Prepare("INSERT INTO urls(url, alias, user_id) VALUES($1, $2, $3)")
+	err := repo.postgres.withTx(ctx, func(tx *sql.Tx) error {
+		return repo.insertURL(ctx, tx, url)
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrUrlExists) {
+			return err
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.ExecContext(ctx, urlToSave, alias, userId)
-	if err != nil {
-		var pqErr *pq.Error
-		if errors.As(err, &pqErr) {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				return repository.ErrUrlExists
+	return nil
+}
+
+// SaveURLs saves all urls in a single transaction. If any url can't be saved none of them are saved
+// and *repository.BatchError pointing to the failed url is returned.
+func (repo *UrlRepositoryPostgres) SaveURLs(ctx context.Context, urls []*entity.Url) error {
+	const op = "database.Postgres.UrlRepository.SaveURLs"
+
+	err := repo.postgres.withTx(ctx, func(tx *sql.Tx) error {
+		for i, url := range urls {
+			if err := repo.insertURL(ctx, tx, url); err != nil {
+				return &repository.BatchError{Index: i, Err: err}
 			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		var batchErr *repository.BatchError
+		if errors.As(err, &batchErr) && errors.Is(batchErr.Err, repository.ErrUrlExists) {
+			return err
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
-func (repo *UrlRepositoryPostgres) GetURL(ctx context.Context, alias string) (string, error) {
+func (repo *UrlRepositoryPostgres) GetURL(ctx context.Context, alias string) (*entity.Url, error) {
 	const op = "database.Postgres.UrlRepository.GetURL"
 
-	stmt, err := repo.postgres.db.Prepare("SELECT url FROM urls WHERE alias=$1")
+	stmt, err := repo.postgres.db.Prepare("SELECT " + urlColumns + " FROM urls WHERE alias=$1")
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var resultUrl string
-	err = stmt.QueryRowContext(ctx, alias).Scan(&resultUrl)
+	url, err := scanURL(stmt.QueryRowContext(ctx, alias))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", repository.ErrURLNotFound
+			return nil, repository.ErrURLNotFound
 		}
 
-		return "", fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return resultUrl, nil
+	return url, nil
+}
+
+// IncrementClicks atomically increments click counter of the url. If the url has reached its clicks limit
+// the counter isn't changed and ErrURLClicksExhausted is returned, so concurrent redirects can't exceed the limit.
+func (repo *UrlRepositoryPostgres) IncrementClicks(ctx context.Context, id int) error {
+	const op = "database.Postgres.UrlRepository.IncrementClicks"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		UPDATE urls SET click_count = click_count + 1
+		WHERE id = $1 AND (max_clicks IS NULL OR click_count < max_clicks)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated == 0 {
+		return repository.ErrURLClicksExhausted
+	}
+
+	return nil
+}
+
+// UpdateURL updates the url owned by url.UserId. If the destination is changed a new url version is recorded.
+func (repo *UrlRepositoryPostgres) UpdateURL(ctx context.Context, url *entity.Url) error {
+	const op = "database.Postgres.UrlRepository.UpdateURL"
+
+	err := repo.postgres.withTx(ctx, func(tx *sql.Tx) error {
+		var oldUrl string
+		err := tx.QueryRowContext(
+			ctx,
+			"SELECT url FROM urls WHERE id = $1 AND user_id = $2 FOR UPDATE",
+			url.Id,
+			url.UserId,
+		).Scan(&oldUrl)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return repository.ErrURLNotFound
+			}
+
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE urls SET url = $1, expires_at = $2, max_clicks = $3, active_from = $4, active_until = $5, fallback_url = $6,
+			redirect_code = $7, forward_query = $8, query_conflict = $9, prefix_mode = $10, title = $11, description = $12,
+			interstitial = $13 WHERE id = $14`,
+			url.Url,
+			url.ExpiresAt,
+			url.MaxClicks,
+			url.ActiveFrom,
+			url.ActiveUntil,
+			sql.NullString{String: url.FallbackUrl, Valid: url.FallbackUrl != ""},
+			url.RedirectCode,
+			url.ForwardQuery,
+			url.QueryConflict,
+			url.Prefix,
+			url.Title,
+			url.Description,
+			url.Interstitial,
+			url.Id,
+		)
+		if err != nil {
+			return err
+		}
+
+		if oldUrl == url.Url {
+			return nil
+		}
+
+		version, err := nextURLVersion(ctx, tx, url.Id)
+		if err != nil {
+			return err
+		}
+
+		return insertURLVersion(ctx, tx, &entity.UrlVersion{
+			UrlId:     url.Id,
+			Version:   version,
+			OldUrl:    oldUrl,
+			NewUrl:    url.Url,
+			ChangedBy: url.UserId,
+			ChangedAt: time.Now().UTC(),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrURLNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (repo *UrlRepositoryPostgres) GetURLVersions(ctx context.Context, urlId int) ([]entity.UrlVersion, error) {
+	const op = "database.Postgres.UrlRepository.GetURLVersions"
+
+	stmt, err := repo.postgres.db.Prepare("SELECT " + urlVersionColumns + " FROM url_versions WHERE url_id = $1 ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, urlId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var versions []entity.UrlVersion
+	for rows.Next() {
+		version, err := scanURLVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		versions = append(versions, *version)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return versions, nil
+}
+
+func (repo *UrlRepositoryPostgres) GetURLVersion(ctx context.Context, urlId int, version int) (*entity.UrlVersion, error) {
+	const op = "database.Postgres.UrlRepository.GetURLVersion"
+
+	stmt, err := repo.postgres.db.Prepare("SELECT " + urlVersionColumns + " FROM url_versions WHERE url_id = $1 AND version = $2")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	urlVersion, err := scanURLVersion(stmt.QueryRowContext(ctx, urlId, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrURLVersionNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urlVersion, nil
 }
 
 func (repo *UrlRepositoryPostgres) DeleteURL(ctx context.Context, alias string, userId int) error {
@@ -84,16 +263,64 @@ func (repo *UrlRepositoryPostgres) DeleteURL(ctx context.Context, alias string,
 	return nil
 }
 
-func (repo *UrlRepositoryPostgres) GetURLsByUserId(ctx context.Context, userId int) ([]entity.Url, error) {
-	const op = "database.Postgres.UrlRepository.GetURLsByUserId"
+func (repo *UrlRepositoryPostgres) DeleteExpiredURLs(ctx context.Context, before time.Time) (int64, error) {
+	const op = "database.Postgres.UrlRepository.DeleteExpiredURLs"
 
-	stmt, err := repo.postgres.db.Prepare("SELECT url, alias FROM urls WHERE user_id = $1")
+	stmt, err := repo.postgres.db.Prepare("DELETE FROM urls WHERE expires_at < $1")
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx, userId)
+	res, err := stmt.ExecContext(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// ListURLs returns a page of user urls in the requested order.
+func (repo *UrlRepositoryPostgres) ListURLs(ctx context.Context, params repository.ListURLsParams) ([]entity.Url, error) {
+	const op = "database.Postgres.UrlRepository.ListURLs"
+
+	sortColumn := "created_at"
+	if params.SortBy == repository.SortByClicks {
+		sortColumn = "click_count"
+	}
+
+	order, cmp := "ASC", ">"
+	if params.Descending {
+		order, cmp = "DESC", "<"
+	}
+
+	query := "SELECT " + urlColumns + " FROM urls WHERE user_id = $1"
+	args := []any{params.UserId}
+
+	if params.Search != "" {
+		args = append(args, "%"+escapeLike(params.Search)+"%")
+		query += fmt.Sprintf(" AND (alias ILIKE $%d OR url ILIKE $%d)", len(args), len(args))
+	}
+
+	if params.After != nil {
+		var sortValue any = params.After.CreatedAt
+		if params.SortBy == repository.SortByClicks {
+			sortValue = params.After.Clicks
+		}
+
+		args = append(args, sortValue, params.After.Id)
+		query += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", sortColumn, cmp, len(args)-1, len(args))
+	}
+
+	args = append(args, params.Limit)
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d", sortColumn, order, order, len(args))
+
+	rows, err := repo.postgres.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -101,16 +328,11 @@ func (repo *UrlRepositoryPostgres) GetURLsByUserId(ctx context.Context, userId i
 
 	var urls []entity.Url
 	for rows.Next() {
-		var url entity.Url
-		err = rows.Scan(&url.Url, &url.Alias)
+		url, err := scanURL(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		urls = append(urls, url)
-	}
-
-	if len(urls) == 0 {
-		return nil, repository.ErrURLsNotFound
+		urls = append(urls, *url)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -119,3 +341,229 @@ func (repo *UrlRepositoryPostgres) GetURLsByUserId(ctx context.Context, userId i
 
 	return urls, nil
 }
+
+// IterateUserURLs calls fn for every url of the user in order of creation without loading all of them into memory.
+func (repo *UrlRepositoryPostgres) IterateUserURLs(ctx context.Context, userId int, fn func(url *entity.Url) error) error {
+	const op = "database.Postgres.UrlRepository.IterateUserURLs"
+
+	stmt, err := repo.postgres.db.Prepare("SELECT " + urlColumns + " FROM urls WHERE user_id = $1 ORDER BY created_at, id")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err = fn(url); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// insertURL inserts the url with its first version and variants. If aliases are case-insensitive, concurrent inserts
+// of the same lowercased alias are serialized with an advisory lock held until the end of tx.
+func (repo *UrlRepositoryPostgres) insertURL(ctx context.Context, tx *sql.Tx, url *entity.Url) error {
+	if repo.caseInsensitiveAliases {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext(lower($1)))", url.Alias); err != nil {
+			return err
+		}
+
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM urls WHERE lower(alias) = lower($1))", url.Alias).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return repository.ErrUrlExists
+		}
+	}
+
+	err := tx.QueryRowContext(
+		ctx,
+		`INSERT INTO urls(url, alias, user_id, expires_at, max_clicks, password_hash, active_from, active_until, fallback_url, redirect_code,
+		forward_query, query_conflict, prefix_mode, title, description, interstitial)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, created_at`,
+		url.Url,
+		url.Alias,
+		url.UserId,
+		url.ExpiresAt,
+		url.MaxClicks,
+		sql.NullString{String: url.PasswordHash, Valid: url.PasswordHash != ""},
+		url.ActiveFrom,
+		url.ActiveUntil,
+		sql.NullString{String: url.FallbackUrl, Valid: url.FallbackUrl != ""},
+		url.RedirectCode,
+		url.ForwardQuery,
+		url.QueryConflict,
+		url.Prefix,
+		url.Title,
+		url.Description,
+		url.Interstitial,
+	).Scan(&url.Id, &url.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return repository.ErrUrlExists
+			}
+		}
+		return err
+	}
+
+	if err = insertURLVariants(ctx, tx, url); err != nil {
+		return err
+	}
+
+	version := &entity.UrlVersion{
+		UrlId:     url.Id,
+		Version:   1,
+		NewUrl:    url.Url,
+		ChangedBy: url.UserId,
+		ChangedAt: time.Now().UTC(),
+	}
+	// the first version of split-testing url records its variants too
+	if url.IsSplit() {
+		version.Variants = url.Variants
+	}
+
+	return insertURLVersion(ctx, tx, version)
+}
+
+// nextURLVersion returns the number of the next version of the url.
+func nextURLVersion(ctx context.Context, tx *sql.Tx, urlId int) (int, error) {
+	var version int
+	err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM url_versions WHERE url_id = $1", urlId).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version + 1, nil
+}
+
+func insertURLVersion(ctx context.Context, tx *sql.Tx, version *entity.UrlVersion) error {
+	var oldUrl *string
+	if version.OldUrl != "" {
+		oldUrl = &version.OldUrl
+	}
+
+	variants, err := marshalVersionVariants(version.Variants)
+	if err != nil {
+		return err
+	}
+
+	return tx.QueryRowContext(
+		ctx,
+		`INSERT INTO url_versions(url_id, version, old_url, new_url, variants, changed_by, changed_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		version.UrlId,
+		version.Version,
+		oldUrl,
+		version.NewUrl,
+		variants,
+		version.ChangedBy,
+		version.ChangedAt,
+	).Scan(&version.Id)
+}
+
+const urlVersionColumns = "id, url_id, version, COALESCE(old_url, ''), new_url, variants, COALESCE(changed_by, 0), changed_at"
+
+// scanURLVersion scans a row selected with urlVersionColumns.
+func scanURLVersion(row rowScanner) (*entity.UrlVersion, error) {
+	var (
+		version  entity.UrlVersion
+		variants []byte
+	)
+	err := row.Scan(
+		&version.Id,
+		&version.UrlId,
+		&version.Version,
+		&version.OldUrl,
+		&version.NewUrl,
+		&variants,
+		&version.ChangedBy,
+		&version.ChangedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if version.Variants, err = unmarshalVersionVariants(variants); err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+const urlColumns = "id, alias, url, COALESCE(user_id, 0), created_at, expires_at, max_clicks, click_count, COALESCE(password_hash, ''), " +
+	"active_from, active_until, COALESCE(fallback_url, ''), redirect_code, " +
+	"forward_query, query_conflict, prefix_mode, title, description, interstitial"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanURL scans a row selected with urlColumns.
+func scanURL(row rowScanner) (*entity.Url, error) {
+	var url entity.Url
+	err := row.Scan(
+		&url.Id,
+		&url.Alias,
+		&url.Url,
+		&url.UserId,
+		&url.CreatedAt,
+		&url.ExpiresAt,
+		&url.MaxClicks,
+		&url.ClickCount,
+		&url.PasswordHash,
+		&url.ActiveFrom,
+		&url.ActiveUntil,
+		&url.FallbackUrl,
+		&url.RedirectCode,
+		&url.ForwardQuery,
+		&url.QueryConflict,
+		&url.Prefix,
+		&url.Title,
+		&url.Description,
+		&url.Interstitial,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &url, nil
+}
+
+// escapeLike escapes LIKE pattern special characters, so the string is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// NextID returns the next value of the sequence used for alias generation.
+func (repo *UrlRepositoryPostgres) NextID(ctx context.Context) (int64, error) {
+	const op = "database.Postgres.UrlRepository.NextID"
+
+	var id int64
+	if err := repo.postgres.db.QueryRowContext(ctx, "SELECT nextval('urls_alias_seq')").Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
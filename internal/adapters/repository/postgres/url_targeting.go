@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+// GetTargetingRules returns targeting rules of the url in the order they are checked.
+func (repo *UrlRepositoryPostgres) GetTargetingRules(ctx context.Context, urlId int) ([]entity.TargetingRule, error) {
+	const op = "database.Postgres.UrlRepository.GetTargetingRules"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		SELECT id, url_id, os, device, browser, bot, country, region, url
+		FROM targeting_rules WHERE url_id = $1 ORDER BY position`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, urlId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var rules []entity.TargetingRule
+	for rows.Next() {
+		var (
+			rule entity.TargetingRule
+			bot  sql.NullBool
+		)
+		err = rows.Scan(
+			&rule.Id,
+			&rule.UrlId,
+			&rule.OS,
+			&rule.Device,
+			&rule.Browser,
+			&bot,
+			&rule.Country,
+			&rule.Region,
+			&rule.Url,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if bot.Valid {
+			rule.Bot = &bot.Bool
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// ReplaceTargetingRules replaces all targeting rules of the url with the given ones keeping their order.
+func (repo *UrlRepositoryPostgres) ReplaceTargetingRules(ctx context.Context, urlId int, rules []entity.TargetingRule) error {
+	const op = "database.Postgres.UrlRepository.ReplaceTargetingRules"
+
+	err := repo.postgres.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM targeting_rules WHERE url_id = $1", urlId); err != nil {
+			return err
+		}
+
+		for i := range rules {
+			rule := &rules[i]
+			rule.UrlId = urlId
+
+			err := tx.QueryRowContext(
+				ctx,
+				`INSERT INTO targeting_rules(url_id, position, os, device, browser, bot, country, region, url)
+				VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+				urlId,
+				i,
+				rule.OS,
+				rule.Device,
+				rule.Browser,
+				rule.Bot,
+				rule.Country,
+				rule.Region,
+				rule.Url,
+			).Scan(&rule.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+// GetURLVariants returns current split-testing variants of the url in the order they were added.
+func (repo *UrlRepositoryPostgres) GetURLVariants(ctx context.Context, urlId int) ([]entity.UrlVariant, error) {
+	const op = "database.Postgres.UrlRepository.GetURLVariants"
+
+	stmt, err := repo.postgres.db.Prepare(`
+		SELECT id, url_id, url, weight FROM url_variants WHERE url_id = $1 AND archived_at IS NULL ORDER BY position`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, urlId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var variants []entity.UrlVariant
+	for rows.Next() {
+		var variant entity.UrlVariant
+		err = rows.Scan(&variant.Id, &variant.UrlId, &variant.Url, &variant.Weight)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variants, nil
+}
+
+// ReplaceURLVariants replaces all variants of the url with url.Variants and records the change as a new
+// url version. Replaced variants are archived, so their clicks stay attributed to them.
+func (repo *UrlRepositoryPostgres) ReplaceURLVariants(ctx context.Context, url *entity.Url) error {
+	const op = "database.Postgres.UrlRepository.ReplaceURLVariants"
+
+	err := repo.postgres.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`UPDATE url_variants SET archived_at = (now() AT TIME ZONE 'UTC') WHERE url_id = $1 AND archived_at IS NULL`,
+			url.Id,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err = insertURLVariants(ctx, tx, url); err != nil {
+			return err
+		}
+
+		version, err := nextURLVersion(ctx, tx, url.Id)
+		if err != nil {
+			return err
+		}
+
+		// empty variants are recorded too, they stop splitting
+		variants := make([]entity.UrlVariant, len(url.Variants))
+		copy(variants, url.Variants)
+
+		return insertURLVersion(ctx, tx, &entity.UrlVersion{
+			UrlId:     url.Id,
+			Version:   version,
+			OldUrl:    url.Url,
+			NewUrl:    url.Url,
+			Variants:  variants,
+			ChangedBy: url.UserId,
+			ChangedAt: time.Now().UTC(),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func insertURLVariants(ctx context.Context, tx *sql.Tx, url *entity.Url) error {
+	for i := range url.Variants {
+		variant := &url.Variants[i]
+		variant.UrlId = url.Id
+
+		err := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO url_variants(url_id, position, url, weight) VALUES($1, $2, $3, $4) RETURNING id`,
+			url.Id,
+			i,
+			variant.Url,
+			variant.Weight,
+		).Scan(&variant.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// versionVariant is a variant stored in url_versions.
+type versionVariant struct {
+	Id     int    `json:"id"`
+	Url    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// marshalVersionVariants returns json of variants set by the version or nil if the version doesn't change them.
+func marshalVersionVariants(variants []entity.UrlVariant) ([]byte, error) {
+	if variants == nil {
+		return nil, nil
+	}
+
+	res := make([]versionVariant, 0, len(variants))
+	for _, variant := range variants {
+		res = append(res, versionVariant{Id: variant.Id, Url: variant.Url, Weight: variant.Weight})
+	}
+
+	return json.Marshal(res)
+}
+
+func unmarshalVersionVariants(data []byte) ([]entity.UrlVariant, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var variants []versionVariant
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, err
+	}
+
+	res := make([]entity.UrlVariant, 0, len(variants))
+	for _, variant := range variants {
+		res = append(res, entity.UrlVariant{Id: variant.Id, Url: variant.Url, Weight: variant.Weight})
+	}
+
+	return res, nil
+}
@@ -52,19 +52,19 @@ func (repo *UserRepositoryPostgres) DeleteUserById(ctx context.Context, id strin
 	}
 
 	res, err := stmt.ExecContext(ctx, id)
-	deleted, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if deleted == 0 {
-		return repository.ErrURLNotFound
-	}
-
+	deleted, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if deleted == 0 {
+		return repository.ErrUserNotFound
+	}
+
 	return nil
 }
 
@@ -96,13 +96,12 @@ func (repo *UserRepositoryPostgres) DeleteUserByLogin(ctx context.Context, login
 func (repo *UserRepositoryPostgres) GetUserById(ctx context.Context, id int) (*entity.User, error) {
 	const op = "database.Postgres.UserRepository.GetUserById"
 
-	stmt, err := repo.postgres.db.Prepare("SELECT id, login, password FROM users WHERE id = $1")
+	stmt, err := repo.postgres.db.Prepare("SELECT id, login, password, role, banned_at FROM users WHERE id = $1")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var user entity.User
-	err = stmt.QueryRowContext(ctx, id).Scan(&user.Id, &user.Login, &user.Password)
+	user, err := scanUser(stmt.QueryRowContext(ctx, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repository.ErrUserNotFound
@@ -111,19 +110,18 @@ func (repo *UserRepositoryPostgres) GetUserById(ctx context.Context, id int) (*e
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (repo *UserRepositoryPostgres) GetUserByLogin(ctx context.Context, login string) (*entity.User, error) {
 	const op = "database.Postgres.UserRepository.GetUserByLogin"
 
-	stmt, err := repo.postgres.db.Prepare("SELECT id, login, password FROM users WHERE login = $1")
+	stmt, err := repo.postgres.db.Prepare("SELECT id, login, password, role, banned_at FROM users WHERE login = $1")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var user entity.User
-	err = stmt.QueryRowContext(ctx, login).Scan(&user.Id, &user.Login, &user.Password)
+	user, err := scanUser(stmt.QueryRowContext(ctx, login))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repository.ErrUserNotFound
@@ -132,13 +130,13 @@ func (repo *UserRepositoryPostgres) GetUserByLogin(ctx context.Context, login st
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (repo *UserRepositoryPostgres) GetUsers(ctx context.Context) ([]entity.User, error) {
 	const op = "database.Postgres.UserRepository.GetUsers"
 
-	stmt, err := repo.postgres.db.Prepare("SELECT id, login, password FROM users")
+	stmt, err := repo.postgres.db.Prepare("SELECT id, login, password, role, banned_at FROM users")
 	if err != nil {
 		return nil, nil
 	}
@@ -154,12 +152,11 @@ func (repo *UserRepositoryPostgres) GetUsers(ctx context.Context) ([]entity.User
 	}
 
 	for rows.Next() {
-		var user entity.User
-		err = rows.Scan(&user.Id, &user.Login, &user.Password)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		users = append(users, user)
+		users = append(users, *user)
 	}
 
 	return users, nil
@@ -180,3 +177,48 @@ func (repo *UserRepositoryPostgres) UpdateUser(ctx context.Context, user *entity
 
 	return nil
 }
+
+// BanUser marks the user as banned, banned users can't sign in.
+func (repo *UserRepositoryPostgres) BanUser(ctx context.Context, id int) error {
+	const op = "database.Postgres.UserRepository.BanUser"
+
+	stmt, err := repo.postgres.db.Prepare("UPDATE users SET banned_at = COALESCE(banned_at, now() AT TIME ZONE 'UTC') WHERE id = $1")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated == 0 {
+		return repository.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row rowScanner) (*entity.User, error) {
+	var (
+		user     entity.User
+		bannedAt sql.NullTime
+	)
+
+	err := row.Scan(&user.Id, &user.Login, &user.Password, &user.Role, &bannedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if bannedAt.Valid {
+		user.BannedAt = &bannedAt.Time
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/lib/pq"
+)
+
+type UtmTemplateRepositoryPostgres struct {
+	postgres *Postgres
+}
+
+func NewUtmTemplateRepository(postgres *Postgres) *UtmTemplateRepositoryPostgres {
+	return &UtmTemplateRepositoryPostgres{postgres: postgres}
+}
+
+const utmTemplateColumns = "id, user_id, name, source, medium, campaign, term, content, created_at"
+
+func (repo *UtmTemplateRepositoryPostgres) SaveUtmTemplate(ctx context.Context, template *entity.UtmTemplate) error {
+	const op = "database.Postgres.UtmTemplateRepository.SaveUtmTemplate"
+
+	stmt, err := repo.postgres.db.Prepare(`INSERT INTO utm_templates(user_id, name, source, medium, campaign, term, content)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(
+		ctx,
+		template.UserId,
+		template.Name,
+		template.Source,
+		template.Medium,
+		template.Campaign,
+		template.Term,
+		template.Content,
+	).Scan(&template.Id, &template.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return repository.ErrUtmTemplateExists
+			}
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (repo *UtmTemplateRepositoryPostgres) GetUtmTemplate(ctx context.Context, userId int, name string) (*entity.UtmTemplate, error) {
+	const op = "database.Postgres.UtmTemplateRepository.GetUtmTemplate"
+
+	stmt, err := repo.postgres.db.Prepare("SELECT " + utmTemplateColumns + " FROM utm_templates WHERE user_id = $1 AND name = $2")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	template, err := scanUtmTemplate(stmt.QueryRowContext(ctx, userId, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrUtmTemplateNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return template, nil
+}
+
+func (repo *UtmTemplateRepositoryPostgres) GetUtmTemplates(ctx context.Context, userId int) ([]entity.UtmTemplate, error) {
+	const op = "database.Postgres.UtmTemplateRepository.GetUtmTemplates"
+
+	stmt, err := repo.postgres.db.Prepare("SELECT " + utmTemplateColumns + " FROM utm_templates WHERE user_id = $1 ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	templates := make([]entity.UtmTemplate, 0)
+	for rows.Next() {
+		template, err := scanUtmTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		templates = append(templates, *template)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return templates, nil
+}
+
+func (repo *UtmTemplateRepositoryPostgres) UpdateUtmTemplate(ctx context.Context, template *entity.UtmTemplate) error {
+	const op = "database.Postgres.UtmTemplateRepository.UpdateUtmTemplate"
+
+	stmt, err := repo.postgres.db.Prepare(`UPDATE utm_templates SET source = $1, medium = $2, campaign = $3, term = $4, content = $5
+		WHERE user_id = $6 AND name = $7 RETURNING id, created_at`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(
+		ctx,
+		template.Source,
+		template.Medium,
+		template.Campaign,
+		template.Term,
+		template.Content,
+		template.UserId,
+		template.Name,
+	).Scan(&template.Id, &template.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrUtmTemplateNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (repo *UtmTemplateRepositoryPostgres) DeleteUtmTemplate(ctx context.Context, userId int, name string) error {
+	const op = "database.Postgres.UtmTemplateRepository.DeleteUtmTemplate"
+
+	stmt, err := repo.postgres.db.Prepare("DELETE FROM utm_templates WHERE user_id = $1 AND name = $2")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, userId, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleted == 0 {
+		return repository.ErrUtmTemplateNotFound
+	}
+
+	return nil
+}
+
+func scanUtmTemplate(row rowScanner) (*entity.UtmTemplate, error) {
+	var template entity.UtmTemplate
+	err := row.Scan(
+		&template.Id,
+		&template.UserId,
+		&template.Name,
+		&template.Source,
+		&template.Medium,
+		&template.Campaign,
+		&template.Term,
+		&template.Content,
+		&template.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
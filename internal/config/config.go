@@ -16,6 +16,19 @@ type Config struct {
 	Secret          string        `env:"SECRET" env-required:"true" env:"SECRET"`
 	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL" env-required:"true"`
 	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL" env-required:"true"`
+	// MaxRefreshSessions is a number of sessions the user can have at once
+	MaxRefreshSessions int `env:"MAX_REFRESH_SESSIONS" env-default:"5"`
+	URLSweeper         URLSweeper
+	ClickPipeline      ClickPipeline
+	Alias              Alias
+	AliasPolicy        AliasPolicy
+	URLUnlock          URLUnlock
+	InactiveURL        InactiveURL
+	Redirect           Redirect
+	GeoIP              GeoIP
+	JWTKeys            JWTKeys
+	TokenRevocation    TokenRevocation
+	SessionSweeper     SessionSweeper
 }
 
 type Postgres struct {
@@ -31,6 +44,80 @@ type HTTPServer struct {
 	Address     string        `env:"HTTP_ADDRESS" env-default:"localhost:8080"`
 	Timeout     time.Duration `env:"TIMEOUT" env-default:"4s"`
 	IdleTimeout time.Duration `env:"IDLE_TIMEOUT" env-default:"60s"`
+	// TrustedProxies are ips and CIDR ranges of proxies which X-Forwarded-For and X-Real-IP headers are trusted
+	TrustedProxies []string `env:"TRUSTED_PROXIES" env-separator:","`
+}
+
+type URLSweeper struct {
+	Interval  time.Duration `env:"URL_SWEEP_INTERVAL" env-default:"1h"`
+	Retention time.Duration `env:"URL_EXPIRED_RETENTION" env-default:"168h"`
+}
+
+type ClickPipeline struct {
+	BufferSize    int           `env:"CLICK_BUFFER_SIZE" env-default:"10000"`
+	Workers       int           `env:"CLICK_WORKERS" env-default:"2"`
+	BatchSize     int           `env:"CLICK_BATCH_SIZE" env-default:"500"`
+	FlushInterval time.Duration `env:"CLICK_FLUSH_INTERVAL" env-default:"1s"`
+	StopTimeout   time.Duration `env:"CLICK_STOP_TIMEOUT" env-default:"10s"`
+}
+
+type Alias struct {
+	Strategy   string `env:"ALIAS_STRATEGY" env-default:"random"`
+	Length     int    `env:"ALIAS_LENGTH" env-default:"6"`
+	Alphabet   string `env:"ALIAS_ALPHABET" env-default:"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"`
+	Salt       string `env:"ALIAS_SALT"`
+	Words      int    `env:"ALIAS_WORDS" env-default:"3"`
+	MaxRetries int    `env:"ALIAS_MAX_RETRIES" env-default:"5"`
+}
+
+type AliasPolicy struct {
+	MinLength        int      `env:"ALIAS_MIN_LENGTH" env-default:"3"`
+	MaxLength        int      `env:"ALIAS_MAX_LENGTH" env-default:"32"`
+	Charset          string   `env:"ALIAS_CHARSET" env-default:"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"`
+	ReservedWords    []string `env:"ALIAS_RESERVED_WORDS" env-separator:","`
+	BlockedWordsFile string   `env:"ALIAS_BLOCKED_WORDS_FILE"`
+	CaseInsensitive  bool     `env:"ALIAS_CASE_INSENSITIVE" env-default:"false"`
+}
+
+type URLUnlock struct {
+	TTL         time.Duration `env:"URL_UNLOCK_TTL" env-default:"15m"`
+	MaxAttempts int           `env:"URL_UNLOCK_MAX_ATTEMPTS" env-default:"5"`
+	// MaxAliasAttempts limits wrong passwords of the url from all ips together
+	MaxAliasAttempts int           `env:"URL_UNLOCK_MAX_ALIAS_ATTEMPTS" env-default:"50"`
+	AttemptsWindow   time.Duration `env:"URL_UNLOCK_ATTEMPTS_WINDOW" env-default:"15m"`
+}
+
+type InactiveURL struct {
+	FallbackURL     string `env:"INACTIVE_URL_FALLBACK"`
+	PlaceholderFile string `env:"INACTIVE_URL_PLACEHOLDER_FILE"`
+}
+
+type Redirect struct {
+	// CacheMaxAge is how long permanent redirects may be cached by browsers and CDNs, they aren't cached if it's zero
+	CacheMaxAge time.Duration `env:"REDIRECT_CACHE_MAX_AGE" env-default:"0s"`
+}
+
+type GeoIP struct {
+	DatabaseFile   string        `env:"GEOIP_DATABASE_FILE"`
+	ReloadInterval time.Duration `env:"GEOIP_RELOAD_INTERVAL" env-default:"1m"`
+}
+
+type JWTKeys struct {
+	ManifestFile string `env:"JWT_KEYS_FILE"`
+	// GracePeriod is how long tokens of retired keys are accepted, access token TTL if not set
+	GracePeriod time.Duration `env:"JWT_KEYS_GRACE_PERIOD"`
+	// HS256Until is the time HS256 tokens issued before switching to keys are accepted until
+	HS256Until time.Time `env:"JWT_HS256_UNTIL"`
+}
+
+type TokenRevocation struct {
+	// SyncInterval is how often revocations made by other instances are loaded
+	SyncInterval time.Duration `env:"TOKEN_REVOCATION_SYNC_INTERVAL" env-default:"1m"`
+}
+
+// SessionSweeper purges refresh session families which newest session has expired.
+type SessionSweeper struct {
+	Interval time.Duration `env:"REFRESH_SESSION_SWEEP_INTERVAL" env-default:"1h"`
 }
 
 func MustLoad() *Config {
@@ -44,6 +131,22 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %s", err)
 	}
 
+	if cfg.MaxRefreshSessions < 1 {
+		log.Fatalf("MAX_REFRESH_SESSIONS must be positive, got %d", cfg.MaxRefreshSessions)
+	}
+
+	if cfg.ClickPipeline.StopTimeout <= 0 {
+		log.Fatalf("CLICK_STOP_TIMEOUT must be positive, got %s", cfg.ClickPipeline.StopTimeout)
+	}
+
+	// tokens signed with a retired key must stay valid until they expire
+	if cfg.JWTKeys.GracePeriod == 0 {
+		cfg.JWTKeys.GracePeriod = cfg.AccessTokenTTL
+	}
+	if cfg.JWTKeys.GracePeriod < cfg.AccessTokenTTL {
+		log.Fatalf("JWT_KEYS_GRACE_PERIOD must be at least ACCESS_TOKEN_TTL (%s), got %s", cfg.AccessTokenTTL, cfg.JWTKeys.GracePeriod)
+	}
+
 	cfg.Postgres.DSNTemplate = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.DatabaseName)
 
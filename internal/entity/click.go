@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+type Click struct {
+	Id          int64
+	UrlId       int
+	ClickedAt   time.Time
+	Referrer    string
+	UserAgent   string
+	VisitorHash string
+	RequestId   string
+	// VariantId is the variant of split-testing url that served the click, 0 for other urls.
+	VariantId int
+}
+
+type UrlStats struct {
+	TotalClicks    int
+	UniqueVisitors int
+	Daily          []DailyClicks
+	// Variants are clicks of every current variant of split-testing url.
+	Variants []VariantClicks
+}
+
+type DailyClicks struct {
+	Day            time.Time
+	Clicks         int
+	UniqueVisitors int
+}
@@ -2,9 +2,26 @@ package entity
 
 import "time"
 
+// RefreshSession is a refresh token of the user. Every refresh rotates the session: it's marked as rotated
+// and a child session with a new token is created in the same family. A family starts on sign in.
 type RefreshSession struct {
-	Id           int
-	UserId       int
-	RefreshToken string
-	ExpiresIn    time.Time
+	Id     int
+	UserId int
+	// RefreshTokenHash is SHA-256 digest of the token, the token itself is never stored.
+	RefreshTokenHash string
+	ExpiresIn        time.Time
+	FamilyId         string
+	ParentId         *int
+	RotatedAt        *time.Time
+	// UserAgent and IP are of the client that has got the session token.
+	UserAgent string
+	IP        string
+	// CreatedAt is the time of sign in, LastUsedAt is the time the session token was got.
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// IsRotated reports whether the session token has been already exchanged for a new one.
+func (s *RefreshSession) IsRotated() bool {
+	return s.RotatedAt != nil
 }
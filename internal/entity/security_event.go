@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+const (
+	// SecurityEventRefreshTokenReuse is recorded when an already rotated refresh token is presented.
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+// SecurityEvent is a suspicious event of the user account.
+type SecurityEvent struct {
+	Id        int
+	UserId    int
+	Type      string
+	Details   string
+	CreatedAt time.Time
+}
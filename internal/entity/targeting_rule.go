@@ -0,0 +1,47 @@
+package entity
+
+import "github.com/4aykovski/url_shortener/pkg/useragent"
+
+// Client describes the one who follows the url.
+type Client struct {
+	useragent.Info
+	// Country is ISO 3166-1 alpha-2 code and Region is ISO 3166-2 code, e.g. US-WA.
+	// They are empty if location of the client is unknown.
+	Country string
+	Region  string
+}
+
+// TargetingRule sends clients matching all its conditions to its own destination.
+// Empty conditions match any client.
+type TargetingRule struct {
+	Id      int
+	UrlId   int
+	OS      string
+	Device  string
+	Browser string
+	// Bot matches only bots if true and only people if false. Nil matches both.
+	Bot     *bool
+	Country string
+	Region  string
+	Url     string
+}
+
+// IsEmpty reports whether the rule has no conditions and so matches every client.
+func (r *TargetingRule) IsEmpty() bool {
+	return r.OS == "" && r.Device == "" && r.Browser == "" && r.Bot == nil && !r.IsGeo()
+}
+
+// IsGeo reports whether the rule depends on location of the client.
+func (r *TargetingRule) IsGeo() bool {
+	return r.Country != "" || r.Region != ""
+}
+
+// Matches reports whether the client satisfies all conditions of the rule.
+func (r *TargetingRule) Matches(client Client) bool {
+	return (r.OS == "" || r.OS == client.OS) &&
+		(r.Device == "" || r.Device == client.Device) &&
+		(r.Browser == "" || r.Browser == client.Browser) &&
+		(r.Bot == nil || *r.Bot == client.Bot) &&
+		(r.Country == "" || r.Country == client.Country) &&
+		(r.Region == "" || r.Region == client.Region)
+}
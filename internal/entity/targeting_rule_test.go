@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/4aykovski/url_shortener/pkg/useragent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUrlTarget(t *testing.T) {
+	human := false
+
+	url := Url{
+		Url: "https://example.com",
+		TargetingRules: []TargetingRule{
+			{OS: useragent.OSIOS, Bot: &human, Url: "https://apps.apple.com/app/id1"},
+			{OS: useragent.OSAndroid, Url: "https://play.google.com/store/apps/details?id=app"},
+			{Device: useragent.DeviceMobile, Url: "https://m.example.com"},
+			{Region: "US-WA", Url: "https://example.com/seattle"},
+			{Country: "US", Url: "https://example.com/us"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		client Client
+		want   string
+	}{
+		{
+			name:   "first matching rule wins",
+			client: Client{Info: useragent.Info{OS: useragent.OSIOS, Device: useragent.DeviceMobile}},
+			want:   "https://apps.apple.com/app/id1",
+		},
+		{
+			name:   "bot condition",
+			client: Client{Info: useragent.Info{OS: useragent.OSIOS, Device: useragent.DeviceMobile, Bot: true}},
+			want:   "https://m.example.com",
+		},
+		{
+			name:   "android",
+			client: Client{Info: useragent.Info{OS: useragent.OSAndroid, Device: useragent.DeviceTablet}},
+			want:   "https://play.google.com/store/apps/details?id=app",
+		},
+		{
+			name:   "region before country",
+			client: Client{Info: useragent.Info{OS: useragent.OSWindows}, Country: "US", Region: "US-WA"},
+			want:   "https://example.com/seattle",
+		},
+		{
+			name:   "country",
+			client: Client{Info: useragent.Info{OS: useragent.OSWindows}, Country: "US", Region: "US-CA"},
+			want:   "https://example.com/us",
+		},
+		{
+			name:   "default destination",
+			client: Client{Info: useragent.Info{OS: useragent.OSWindows, Device: useragent.DeviceDesktop}},
+			want:   "https://example.com",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, url.Target(tc.client))
+		})
+	}
+}
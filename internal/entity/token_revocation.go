@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// RevokedToken is an access token revoked before it expires, e.g. on logout.
+type RevokedToken struct {
+	Jti       string
+	UserId    int
+	ExpiresAt time.Time
+}
+
+// UserTokenRevocation revokes all access tokens of the user issued before RevokedBefore,
+// e.g. on password change. It's kept until ExpiresAt, when all of these tokens are expired.
+type UserTokenRevocation struct {
+	UserId        int
+	RevokedBefore time.Time
+	ExpiresAt     time.Time
+}
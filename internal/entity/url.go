@@ -1,8 +1,81 @@
 package entity
 
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultRedirectCode is used for urls created without redirect code.
+const DefaultRedirectCode = http.StatusFound
+
 type Url struct {
-	Id     int
-	Alias  string
-	Url    string
-	UserId int
+	Id        int
+	Alias     string
+	Url       string
+	UserId    int
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	// MaxClicks is a number of redirects after which the url stops working. Nil means unlimited.
+	MaxClicks  *int
+	ClickCount int
+	// PasswordHash is a bcrypt hash of the password required to follow the url. Empty means no password.
+	PasswordHash string
+	// ActiveFrom and ActiveUntil limit the time the url redirects to its destination. Nil means no limit.
+	ActiveFrom  *time.Time
+	ActiveUntil *time.Time
+	// FallbackUrl is used instead of the destination outside of the activation window. Empty means no fallback.
+	FallbackUrl string
+	// RedirectCode is http status of redirect: 301, 302, 307 or 308.
+	RedirectCode int
+	// ForwardQuery merges query of the short url into the destination according to QueryConflict rule.
+	ForwardQuery  bool
+	QueryConflict string
+	// Prefix urls append the rest of the short url path to the destination path.
+	Prefix bool
+	// TargetingRules are checked in order, the first matching rule replaces the destination.
+	// Url is the default destination for clients matching no rule.
+	TargetingRules []TargetingRule
+	// Title and Description are shown on the preview page of the url.
+	Title       string
+	Description string
+	// Interstitial urls show the preview page before every redirect.
+	Interstitial bool
+	// Variants split visitors matching no targeting rule between several destinations,
+	// Url isn't used for redirects of such urls.
+	Variants []UrlVariant
+}
+
+// HasGeoTargeting reports whether any targeting rule of the url depends on location of the client.
+func (u *Url) HasGeoTargeting() bool {
+	for i := range u.TargetingRules {
+		if u.TargetingRules[i].IsGeo() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the url has an expiration time that is already passed.
+func (u *Url) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(now)
+}
+
+// IsNotYetActive reports whether the activation window of the url hasn't started yet.
+func (u *Url) IsNotYetActive(now time.Time) bool {
+	return u.ActiveFrom != nil && u.ActiveFrom.After(now)
+}
+
+// IsNoLongerActive reports whether the activation window of the url is already over.
+func (u *Url) IsNoLongerActive(now time.Time) bool {
+	return u.ActiveUntil != nil && !u.ActiveUntil.After(now)
+}
+
+// IsPermanentRedirect reports whether the url redirects with permanent status code.
+func (u *Url) IsPermanentRedirect() bool {
+	return u.RedirectCode == http.StatusMovedPermanently || u.RedirectCode == http.StatusPermanentRedirect
+}
+
+// IsProtected reports whether the url requires a password.
+func (u *Url) IsProtected() bool {
+	return u.PasswordHash != ""
 }
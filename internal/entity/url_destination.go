@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Query conflict rules decide what to do with incoming query params that are already in the destination.
+const (
+	// QueryConflictKeep keeps destination value.
+	QueryConflictKeep = "keep"
+	// QueryConflictOverride replaces destination value with incoming one.
+	QueryConflictOverride = "override"
+	// QueryConflictAppend keeps both values.
+	QueryConflictAppend = "append"
+)
+
+var ErrInvalidDestinationPath = errors.New("invalid destination path")
+
+// Destination returns the url to redirect to. Path is appended to the destination path of prefix urls,
+// query is merged into the destination query if the url forwards query.
+func (u *Url) Destination(path string, query url.Values) (string, error) {
+	return u.DestinationFrom(u.Url, path, query)
+}
+
+// Target returns destination of the first targeting rule matching the client or the url itself.
+func (u *Url) Target(client Client) string {
+	if rule := u.MatchRule(client); rule != nil {
+		return rule.Url
+	}
+	return u.Url
+}
+
+// MatchRule returns the first targeting rule matching the client or nil.
+func (u *Url) MatchRule(client Client) *TargetingRule {
+	for i := range u.TargetingRules {
+		if u.TargetingRules[i].Matches(client) {
+			return &u.TargetingRules[i]
+		}
+	}
+	return nil
+}
+
+// DestinationFrom is like Destination, but starts from the given base instead of the url itself.
+// Base is a destination of targeting rule or variant of the url.
+func (u *Url) DestinationFrom(base string, path string, query url.Values) (string, error) {
+	if (path == "" || !u.Prefix) && (len(query) == 0 || !u.ForwardQuery) {
+		return base, nil
+	}
+
+	dest, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	if path != "" && u.Prefix {
+		if !ValidDestinationPath(path) {
+			return "", ErrInvalidDestinationPath
+		}
+
+		dest = dest.JoinPath(path)
+	}
+
+	if len(query) != 0 && u.ForwardQuery {
+		dest.RawQuery = mergeQuery(dest.Query(), query, u.QueryConflict).Encode()
+	}
+
+	return dest.String(), nil
+}
+
+// ValidDestinationPath reports whether the path can be appended to destination without escaping its path,
+// i.e. it has no dot segments.
+func ValidDestinationPath(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeQuery(dest url.Values, incoming url.Values, conflict string) url.Values {
+	for key, values := range incoming {
+		if _, ok := dest[key]; !ok {
+			dest[key] = values
+			continue
+		}
+
+		switch conflict {
+		case QueryConflictOverride:
+			dest[key] = values
+		case QueryConflictAppend:
+			dest[key] = append(dest[key], values...)
+		}
+	}
+
+	return dest
+}
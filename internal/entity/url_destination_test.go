@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUrlDestination(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     Url
+		path    string
+		query   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "query is dropped by default",
+			url:   Url{Url: "https://example.com/page?a=1"},
+			query: "utm_source=x",
+			want:  "https://example.com/page?a=1",
+		},
+		{
+			name:  "query is merged",
+			url:   Url{Url: "https://example.com/page?a=1", ForwardQuery: true, QueryConflict: QueryConflictKeep},
+			query: "utm_source=x",
+			want:  "https://example.com/page?a=1&utm_source=x",
+		},
+		{
+			name:  "conflict keeps destination value",
+			url:   Url{Url: "https://example.com/?a=1", ForwardQuery: true, QueryConflict: QueryConflictKeep},
+			query: "a=2",
+			want:  "https://example.com/?a=1",
+		},
+		{
+			name:  "conflict overrides destination value",
+			url:   Url{Url: "https://example.com/?a=1", ForwardQuery: true, QueryConflict: QueryConflictOverride},
+			query: "a=2",
+			want:  "https://example.com/?a=2",
+		},
+		{
+			name:  "conflict appends incoming value",
+			url:   Url{Url: "https://example.com/?a=1", ForwardQuery: true, QueryConflict: QueryConflictAppend},
+			query: "a=2",
+			want:  "https://example.com/?a=1&a=2",
+		},
+		{
+			name: "path is appended to prefix url",
+			url:  Url{Url: "https://example.com/docs/", Prefix: true},
+			path: "guide/intro.html",
+			want: "https://example.com/docs/guide/intro.html",
+		},
+		{
+			name:  "path and query",
+			url:   Url{Url: "https://example.com/docs?v=1", Prefix: true, ForwardQuery: true, QueryConflict: QueryConflictKeep},
+			path:  "guide",
+			query: "lang=en",
+			want:  "https://example.com/docs/guide?lang=en&v=1",
+		},
+		{
+			name:    "dot segments are rejected",
+			url:     Url{Url: "https://example.com/docs/", Prefix: true},
+			path:    "../admin",
+			wantErr: ErrInvalidDestinationPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			got, err := tt.url.Destination(tt.path, query)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package entity
+
+// UrlVariant is one of the destinations of a split-testing url. Visitors are distributed
+// between variants proportionally to their weights.
+type UrlVariant struct {
+	Id     int
+	UrlId  int
+	Url    string
+	Weight int
+}
+
+// VariantClicks are clicks served by the variant. Archived variants have been replaced
+// by other variants, their clicks are kept.
+type VariantClicks struct {
+	VariantId      int
+	Url            string
+	Weight         int
+	Archived       bool
+	Clicks         int
+	UniqueVisitors int
+}
+
+// IsSplit reports whether the url splits visitors between several variants.
+func (u *Url) IsSplit() bool {
+	return len(u.Variants) != 0
+}
+
+// Variant returns the variant with the given id or nil if the url has no such variant.
+func (u *Url) Variant(id int) *UrlVariant {
+	for i := range u.Variants {
+		if u.Variants[i].Id == id {
+			return &u.Variants[i]
+		}
+	}
+	return nil
+}
+
+// TotalWeight returns sum of weights of all variants.
+func (u *Url) TotalWeight() int {
+	total := 0
+	for _, variant := range u.Variants {
+		total += variant.Weight
+	}
+	return total
+}
+
+// PickVariant returns the variant covering point n of [0, TotalWeight) range, where every variant
+// covers the part of the range equal to its weight. It returns nil if n is out of range.
+func (u *Url) PickVariant(n int) *UrlVariant {
+	for i := range u.Variants {
+		if n < u.Variants[i].Weight {
+			return &u.Variants[i]
+		}
+		n -= u.Variants[i].Weight
+	}
+	return nil
+}
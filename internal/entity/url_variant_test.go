@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUrlPickVariant(t *testing.T) {
+	url := Url{
+		Variants: []UrlVariant{
+			{Id: 1, Url: "https://example.com/a", Weight: 70},
+			{Id: 2, Url: "https://example.com/b", Weight: 30},
+		},
+	}
+
+	require.Equal(t, 100, url.TotalWeight())
+
+	picked := make(map[int]int)
+	for n := 0; n < url.TotalWeight(); n++ {
+		variant := url.PickVariant(n)
+		require.NotNil(t, variant)
+		picked[variant.Id]++
+	}
+
+	assert.Equal(t, map[int]int{1: 70, 2: 30}, picked)
+	assert.Nil(t, url.PickVariant(100))
+	assert.Equal(t, "https://example.com/b", url.Variant(2).Url)
+	assert.Nil(t, url.Variant(3))
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// UrlVersion is a change of the url destination or its split-testing variants. The first version of the url
+// has empty OldUrl.
+type UrlVersion struct {
+	Id      int
+	UrlId   int
+	Version int
+	OldUrl  string
+	NewUrl  string
+	// Variants are set by the version if it changes split-testing variants, empty Variants stop splitting.
+	// Destination isn't changed by such versions. Variants are nil for destination changes.
+	Variants  []UrlVariant
+	ChangedBy int
+	ChangedAt time.Time
+}
+
+// IsVariantsChange reports whether the version changes split-testing variants instead of destination.
+func (v *UrlVersion) IsVariantsChange() bool {
+	return v.Variants != nil
+}
@@ -1,7 +1,27 @@
 package entity
 
+import "time"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	Id       int
 	Login    string
 	Password string
+	Role     string
+	// BannedAt is nil if the user isn't banned.
+	BannedAt *time.Time
+}
+
+// IsBanned reports whether the user is banned by admin.
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
+// IsAdmin reports whether the user can manage other users.
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
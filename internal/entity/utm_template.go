@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// UtmTemplate is a named set of default UTM parameters of the user.
+type UtmTemplate struct {
+	Id        int
+	UserId    int
+	Name      string
+	Source    string
+	Medium    string
+	Campaign  string
+	Term      string
+	Content   string
+	CreatedAt time.Time
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/4aykovski/url_shortener/internal/adapters/repository"
@@ -19,6 +20,7 @@ type userRepository interface {
 	GetUserByLogin(ctx context.Context, login string) (*entity.User, error)
 	GetUsers(ctx context.Context) ([]entity.User, error)
 	UpdateUser(ctx context.Context, user *entity.User) error
+	BanUser(ctx context.Context, id int) error
 }
 
 type passHasher interface {
@@ -26,36 +28,55 @@ type passHasher interface {
 	CheckPassword(password string, hashedPassword string) bool
 }
 type refreshSessionService interface {
-	CreateRefreshSession(ctx context.Context, userId int) (*tokenManager.Tokens, error)
+	CreateRefreshSession(ctx context.Context, userId int, client SessionClient) (*tokenManager.Tokens, error)
 	GetAllUserRefreshSessions(ctx context.Context, userId int) ([]entity.RefreshSession, error)
 	DeleteEarliestRefreshSession(ctx context.Context, sessions []entity.RefreshSession) error
 	DeleteRefreshSession(ctx context.Context, refreshToken string) error
-	ValidateRefreshSession(ctx context.Context, refreshToken string) (int, error)
+	RotateRefreshSession(ctx context.Context, refreshToken string, client SessionClient) (*tokenManager.Tokens, error)
+	DeleteUserRefreshSession(ctx context.Context, userId int, sessionId int) error
+	DeleteUserRefreshSessions(ctx context.Context, userId int) error
 }
 
+type tokenRevoker interface {
+	RevokeAccessToken(ctx context.Context, accessToken string) error
+	RevokeUserTokens(ctx context.Context, userId int) error
+}
+
+// ErrUserBanned is returned on sign in of banned users.
+var ErrUserBanned = errors.New("user banned")
+
 type AuthService struct {
 	userRepo              userRepository
 	refreshSessionService refreshSessionService
+	tokenRevoker          tokenRevoker
 
 	hasher passHasher
 
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
+
+	// maxRefreshSessions is a number of sessions the user can have, the least recently used one is revoked
+	// on sign in over the limit.
+	maxRefreshSessions int
 }
 
 func NewAuthService(
 	userRepo userRepository,
 	refreshSessionService refreshSessionService,
+	tokenRevoker tokenRevoker,
 	hasher passHasher,
 	accessTokenTTL time.Duration,
 	refreshTokenTTL time.Duration,
+	maxRefreshSessions int,
 ) *AuthService {
 	return &AuthService{
 		userRepo:              userRepo,
 		refreshSessionService: refreshSessionService,
+		tokenRevoker:          tokenRevoker,
 		hasher:                hasher,
 		accessTokenTTL:        accessTokenTTL,
 		refreshTokenTTL:       refreshTokenTTL,
+		maxRefreshSessions:    maxRefreshSessions,
 	}
 }
 
@@ -88,6 +109,7 @@ func (s *AuthService) SignUp(ctx context.Context, input AuthSignUpInput) error {
 type AuthSignInInput struct {
 	Login    string
 	Password string
+	Client   SessionClient
 }
 
 var ErrWrongCred = errors.New("wrong credentials")
@@ -105,7 +127,7 @@ func (s *AuthService) SignIn(ctx context.Context, input AuthSignInInput) (*token
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	tokens, err := s.refreshSessionService.CreateRefreshSession(ctx, user.Id)
+	tokens, err := s.refreshSessionService.CreateRefreshSession(ctx, user.Id, input.Client)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -113,10 +135,30 @@ func (s *AuthService) SignIn(ctx context.Context, input AuthSignInInput) (*token
 	return tokens, nil
 }
 
-func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+type AuthLogoutInput struct {
+	RefreshToken string
+	// AccessToken is revoked with the session if it's given.
+	AccessToken string
+}
+
+// Logout deletes the session. The access token is revoked first, so it's revoked even if the session
+// is already gone, e.g. its refresh token has expired or been rotated. The refresh token may be empty
+// if only the access token is revoked.
+func (s *AuthService) Logout(ctx context.Context, input AuthLogoutInput) error {
 	const op = "services.user.Logout"
 
-	err := s.refreshSessionService.DeleteRefreshSession(ctx, refreshToken)
+	if input.AccessToken != "" {
+		err := s.tokenRevoker.RevokeAccessToken(ctx, input.AccessToken)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if input.RefreshToken == "" {
+		return nil
+	}
+
+	err := s.refreshSessionService.DeleteRefreshSession(ctx, input.RefreshToken)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -124,20 +166,189 @@ func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	return nil
 }
 
-func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*tokenManager.Tokens, error) {
+type AuthRefreshInput struct {
+	RefreshToken string
+	Client       SessionClient
+}
+
+func (s *AuthService) Refresh(ctx context.Context, input AuthRefreshInput) (*tokenManager.Tokens, error) {
 	const op = "services.user.Refresh"
 
-	userId, err := s.refreshSessionService.ValidateRefreshSession(ctx, refreshToken)
+	tokens, err := s.refreshSessionService.RotateRefreshSession(ctx, input.RefreshToken, input.Client)
 	if err != nil {
-		return &tokenManager.Tokens{}, fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	tokens, err := s.refreshSessionService.CreateRefreshSession(ctx, userId)
+	return tokens, nil
+}
+
+// GetSessions returns active sessions of the user, the most recently used first.
+func (s *AuthService) GetSessions(ctx context.Context, userId int) ([]entity.RefreshSession, error) {
+	const op = "services.user.GetSessions"
+
+	sessions, err := s.refreshSessionService.GetAllUserRefreshSessions(ctx, userId)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return tokens, nil
+	return sessions, nil
+}
+
+type RevokeSessionInput struct {
+	UserId    int
+	SessionId int
+}
+
+// RevokeSession logs the user out of the session.
+func (s *AuthService) RevokeSession(ctx context.Context, input RevokeSessionInput) error {
+	const op = "services.user.RevokeSession"
+
+	err := s.refreshSessionService.DeleteUserRefreshSession(ctx, input.UserId, input.SessionId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere, issued access tokens are revoked too.
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userId int) error {
+	const op = "services.user.RevokeAllSessions"
+
+	err := s.revokeUserAccess(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+type ChangePasswordInput struct {
+	UserId      int
+	OldPassword string
+	NewPassword string
+}
+
+// ChangePassword sets the new password and logs the user out everywhere, access tokens included.
+func (s *AuthService) ChangePassword(ctx context.Context, input ChangePasswordInput) error {
+	const op = "services.user.ChangePassword"
+
+	user, err := s.getUserByIdWithPassword(ctx, input.UserId, input.OldPassword)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user.Password, err = s.hasher.Hash(input.NewPassword)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userRepo.UpdateUser(ctx, user)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.revokeUserAccess(ctx, user.Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+type DeleteAccountInput struct {
+	UserId   int
+	Password string
+}
+
+// DeleteAccount deletes the user and revokes their access tokens.
+func (s *AuthService) DeleteAccount(ctx context.Context, input DeleteAccountInput) error {
+	const op = "services.user.DeleteAccount"
+
+	user, err := s.getUserByIdWithPassword(ctx, input.UserId, input.Password)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// tokens are revoked first, so they can't be used if deletion fails halfway
+	err = s.tokenRevoker.RevokeUserTokens(ctx, user.Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userRepo.DeleteUserById(ctx, strconv.Itoa(user.Id))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// BanUser bans the user: the user is logged out everywhere and can't sign in anymore.
+func (s *AuthService) BanUser(ctx context.Context, userId int) error {
+	const op = "services.user.BanUser"
+
+	err := s.userRepo.BanUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.revokeUserAccess(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IsAdmin reports whether the user is admin. The role is checked on every request instead of being put
+// into access tokens, so revoked admin rights apply at once.
+func (s *AuthService) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	const op = "services.user.IsAdmin"
+
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user.IsAdmin() && !user.IsBanned(), nil
+}
+
+// revokeUserAccess deletes all sessions of the user and revokes issued access tokens.
+func (s *AuthService) revokeUserAccess(ctx context.Context, userId int) error {
+	const op = "services.user.revokeUserAccess"
+
+	err := s.refreshSessionService.DeleteUserRefreshSessions(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.tokenRevoker.RevokeUserTokens(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// getUserByIdWithPassword returns the user if the password is valid, otherwise returns ErrWrongCred.
+func (s *AuthService) getUserByIdWithPassword(ctx context.Context, userId int, password string) (*entity.User, error) {
+	const op = "services.user.getUserByIdWithPassword"
+
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !s.hasher.CheckPassword(password, user.Password) {
+		return nil, fmt.Errorf("%s: %w", op, ErrWrongCred)
+	}
+
+	return user, nil
 }
 
 // getUserWithCreds checks if credentials are valid. If it's valid returns user, otherwise returns nil and error
@@ -158,6 +369,10 @@ func (s *AuthService) getUserWithCreds(ctx context.Context, login, password stri
 		return nil, fmt.Errorf("%s: %w", op, ErrWrongCred)
 	}
 
+	if user.IsBanned() {
+		return nil, fmt.Errorf("%s: %w", op, ErrUserBanned)
+	}
+
 	return user, nil
 }
 
@@ -169,11 +384,15 @@ func (s *AuthService) removeExcessRefreshSession(ctx context.Context, userId int
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if len(sessions) >= 5 {
+	// the limit could be lowered, so several sessions may be over it
+	for len(sessions) >= s.maxRefreshSessions {
 		err = s.refreshSessionService.DeleteEarliestRefreshSession(ctx, sessions)
 		if err != nil {
 			return fmt.Errorf("%s: %w", op, err)
 		}
+
+		// the earliest session is the first one after DeleteEarliestRefreshSession
+		sessions = sessions[1:]
 	}
 
 	return nil
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	tokenManager "github.com/4aykovski/url_shortener/pkg/manager/token"
+	"github.com/4aykovski/url_shortener/pkg/revocation"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUserRepository struct {
+	userRepository
+	user *entity.User
+}
+
+func (r *fakeUserRepository) GetUserByLogin(ctx context.Context, login string) (*entity.User, error) {
+	return r.user, nil
+}
+
+func (r *fakeUserRepository) BanUser(ctx context.Context, id int) error {
+	now := time.Now()
+	r.user.BannedAt = &now
+	return nil
+}
+
+type fakeRefreshSessionService struct {
+	refreshSessionService
+	deletedUserId int
+}
+
+func (s *fakeRefreshSessionService) DeleteUserRefreshSessions(ctx context.Context, userId int) error {
+	s.deletedUserId = userId
+	return nil
+}
+
+func (s *fakeRefreshSessionService) DeleteRefreshSession(ctx context.Context, refreshToken string) error {
+	return repository.ErrRefreshSessionNotFound
+}
+
+type fakeTokenRevocationRepository struct {
+	tokenRevocationRepository
+}
+
+func (r fakeTokenRevocationRepository) CreateRevokedToken(ctx context.Context, token *entity.RevokedToken) error {
+	return nil
+}
+
+func (r fakeTokenRevocationRepository) SaveUserTokenRevocation(ctx context.Context, revocation *entity.UserTokenRevocation) error {
+	return nil
+}
+
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error) { return password, nil }
+
+func (plainHasher) CheckPassword(password string, hashedPassword string) bool {
+	return password == hashedPassword
+}
+
+type authServiceTest struct {
+	s           *AuthService
+	tM          *tokenManager.Manager
+	revocations *TokenRevocationService
+	users       *fakeUserRepository
+	sessions    *fakeRefreshSessionService
+}
+
+func newAuthServiceTest() authServiceTest {
+	tM := tokenManager.NewManager("secret")
+	revocations := NewTokenRevocationService(fakeTokenRevocationRepository{}, revocation.NewStore(), tM, time.Minute)
+	users := &fakeUserRepository{user: &entity.User{Id: 1, Login: "login", Password: "password"}}
+	sessions := &fakeRefreshSessionService{}
+
+	return authServiceTest{
+		s:           NewAuthService(users, sessions, revocations, plainHasher{}, time.Minute, time.Hour, 5),
+		tM:          tM,
+		revocations: revocations,
+		users:       users,
+		sessions:    sessions,
+	}
+}
+
+// newAccessToken creates the access token of the user 1 and returns the func reporting whether it's revoked.
+func (a authServiceTest) newAccessToken(t *testing.T) (string, func() bool) {
+	tokens, err := a.tM.CreateTokensPair("1", time.Minute)
+	require.NoError(t, err)
+
+	claims, err := a.tM.Parse(tokens.AccessToken)
+	require.NoError(t, err)
+	issuedAt, err := claims.GetIssuedAt()
+	require.NoError(t, err)
+
+	return tokens.AccessToken, func() bool {
+		return a.revocations.IsTokenRevoked(claims["jti"].(string), "1", issuedAt.Time)
+	}
+}
+
+func TestAuthServiceLogout(t *testing.T) {
+	t.Parallel()
+
+	a := newAuthServiceTest()
+
+	accessToken, isRevoked := a.newAccessToken(t)
+	require.False(t, isRevoked())
+
+	err := a.s.Logout(context.Background(), AuthLogoutInput{RefreshToken: "rotated", AccessToken: accessToken})
+	require.ErrorIs(t, err, repository.ErrRefreshSessionNotFound)
+
+	require.True(t, isRevoked(), "access token is revoked even if the session is gone")
+}
+
+func TestAuthServiceRevokeAllSessions(t *testing.T) {
+	t.Parallel()
+
+	a := newAuthServiceTest()
+
+	_, isRevoked := a.newAccessToken(t)
+
+	require.NoError(t, a.s.RevokeAllSessions(context.Background(), 1))
+
+	require.True(t, isRevoked())
+	require.Equal(t, 1, a.sessions.deletedUserId)
+}
+
+func TestAuthServiceBanUser(t *testing.T) {
+	t.Parallel()
+
+	a := newAuthServiceTest()
+
+	_, isRevoked := a.newAccessToken(t)
+	require.False(t, isRevoked())
+
+	require.NoError(t, a.s.BanUser(context.Background(), 1))
+
+	require.True(t, isRevoked(), "access tokens of banned user are rejected")
+	require.Equal(t, 1, a.sessions.deletedUserId, "sessions of banned user are deleted")
+
+	_, err := a.s.SignIn(context.Background(), AuthSignInInput{Login: "login", Password: "password"})
+	require.ErrorIs(t, err, ErrUserBanned)
+}
+
+func TestAuthServiceSignInAfterRevocation(t *testing.T) {
+	t.Parallel()
+
+	a := newAuthServiceTest()
+
+	_, isOldRevoked := a.newAccessToken(t)
+
+	require.NoError(t, a.s.RevokeAllSessions(context.Background(), 1))
+
+	// e.g. the user signs in right after changing the password
+	_, isNewRevoked := a.newAccessToken(t)
+
+	require.True(t, isOldRevoked())
+	require.False(t, isNewRevoked(), "tokens issued after revocation are valid")
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+type clickRepository interface {
+	GetClicksTotal(ctx context.Context, urlId int) (int, int, error)
+	GetDailyClicks(ctx context.Context, urlId int, from time.Time, to time.Time) ([]entity.DailyClicks, error)
+	GetVariantClicks(ctx context.Context, urlId int) ([]entity.VariantClicks, error)
+}
+
+type clickQueue interface {
+	Enqueue(click entity.Click) bool
+}
+
+type urlGetter interface {
+	GetURL(ctx context.Context, alias string) (*entity.Url, error)
+	GetURLVariants(ctx context.Context, urlId int) ([]entity.UrlVariant, error)
+}
+
+type ClickService struct {
+	clickRepository clickRepository
+	clickQueue      clickQueue
+	urlRepository   urlGetter
+
+	// visitorSalt is mixed into visitor ip before hashing, so raw ips can't be recovered from stored hashes
+	visitorSalt string
+}
+
+func NewClickService(
+	clickRepository clickRepository,
+	clickQueue clickQueue,
+	urlRepository urlGetter,
+	visitorSalt string,
+) *ClickService {
+	return &ClickService{
+		clickRepository: clickRepository,
+		clickQueue:      clickQueue,
+		urlRepository:   urlRepository,
+		visitorSalt:     visitorSalt,
+	}
+}
+
+type RecordClickInput struct {
+	UrlId     int
+	Referrer  string
+	UserAgent string
+	IP        string
+	RequestId string
+	// VariantId is the variant of split-testing url the client was redirected to.
+	VariantId int
+}
+
+// RecordClick queues the click to be saved asynchronously. It never blocks the caller,
+// if the queue is full the click is dropped and ErrClickDropped is returned.
+func (s *ClickService) RecordClick(ctx context.Context, input RecordClickInput) error {
+	click := entity.Click{
+		UrlId:       input.UrlId,
+		ClickedAt:   time.Now().UTC(),
+		Referrer:    input.Referrer,
+		UserAgent:   input.UserAgent,
+		VisitorHash: s.visitorHash(input.IP),
+		RequestId:   input.RequestId,
+		VariantId:   input.VariantId,
+	}
+
+	if ok := s.clickQueue.Enqueue(click); !ok {
+		return fmt.Errorf("failed to queue click: %w", ErrClickDropped)
+	}
+
+	return nil
+}
+
+const (
+	defaultStatsPeriod = 30 * 24 * time.Hour
+	// maxStatsPeriod bounds the daily time series, it's built day by day.
+	maxStatsPeriod = 366 * 24 * time.Hour
+)
+
+type GetURLStatsInput struct {
+	Alias  string
+	UserId int
+	// From and To bound the daily time series. If empty the last 30 days are used.
+	// The period can't be longer than 366 days.
+	From time.Time
+	To   time.Time
+}
+
+func (s *ClickService) GetURLStats(ctx context.Context, input GetURLStatsInput) (*entity.UrlStats, error) {
+	url, err := s.urlRepository.GetURL(ctx, input.Alias)
+	if err != nil {
+		if errors.Is(err, repository.ErrURLNotFound) {
+			return nil, fmt.Errorf("url not found: %w", ErrURLNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get url: %w", err)
+	}
+
+	if url.UserId != input.UserId {
+		return nil, fmt.Errorf("url not found: %w", ErrURLNotFound)
+	}
+
+	from, to, err := statsPeriod(input.From, input.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats entity.UrlStats
+	stats.TotalClicks, stats.UniqueVisitors, err = s.clickRepository.GetClicksTotal(ctx, url.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clicks total: %w", err)
+	}
+
+	daily, err := s.clickRepository.GetDailyClicks(ctx, url.Id, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily clicks: %w", err)
+	}
+	stats.Daily = fillDailyClicks(daily, from, to)
+
+	if stats.Variants, err = s.variantClicks(ctx, url.Id); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// variantClicks returns clicks of every current variant of the url, including variants without clicks,
+// followed by archived variants that have clicks.
+func (s *ClickService) variantClicks(ctx context.Context, urlId int) ([]entity.VariantClicks, error) {
+	variants, err := s.urlRepository.GetURLVariants(ctx, urlId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url variants: %w", err)
+	}
+
+	clicks, err := s.clickRepository.GetVariantClicks(ctx, urlId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant clicks: %w", err)
+	}
+
+	if len(variants) == 0 && len(clicks) == 0 {
+		return nil, nil
+	}
+
+	byVariant := make(map[int]entity.VariantClicks, len(clicks))
+	for _, variant := range clicks {
+		byVariant[variant.VariantId] = variant
+	}
+
+	res := make([]entity.VariantClicks, 0, len(variants)+len(clicks))
+	for _, variant := range variants {
+		variantClicks := byVariant[variant.Id]
+		variantClicks.VariantId = variant.Id
+		variantClicks.Url = variant.Url
+		variantClicks.Weight = variant.Weight
+		res = append(res, variantClicks)
+	}
+
+	for _, variant := range clicks {
+		if variant.Archived {
+			res = append(res, variant)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *ClickService) visitorHash(ip string) string {
+	sum := sha256.Sum256([]byte(s.visitorSalt + ip))
+	return hex.EncodeToString(sum[:])
+}
+
+// statsPeriod truncates the period to whole days and applies defaults. The returned period is [from, to).
+// Periods longer than maxStatsPeriod are rejected.
+func statsPeriod(from time.Time, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	to = to.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	if from.IsZero() {
+		from = to.Add(-defaultStatsPeriod)
+	}
+	from = from.UTC().Truncate(24 * time.Hour)
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from is after to: %w", ErrInvalidStatsPeriod)
+	}
+
+	if to.Sub(from) > maxStatsPeriod {
+		return time.Time{}, time.Time{}, fmt.Errorf("period is longer than %d days: %w", maxStatsPeriod/(24*time.Hour), ErrInvalidStatsPeriod)
+	}
+
+	return from, to, nil
+}
+
+// fillDailyClicks returns a continuous time series with zero values for days without clicks.
+func fillDailyClicks(daily []entity.DailyClicks, from time.Time, to time.Time) []entity.DailyClicks {
+	byDay := make(map[time.Time]entity.DailyClicks, len(daily))
+	for _, day := range daily {
+		byDay[day.Day.UTC().Truncate(24*time.Hour)] = day
+	}
+
+	var res []entity.DailyClicks
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		clicks := byDay[day]
+		clicks.Day = day
+		res = append(res, clicks)
+	}
+
+	return res
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVariantsRepository struct {
+	urlGetter
+	clickRepository
+	variants []entity.UrlVariant
+	clicks   []entity.VariantClicks
+}
+
+func (r fakeVariantsRepository) GetURLVariants(ctx context.Context, urlId int) ([]entity.UrlVariant, error) {
+	return r.variants, nil
+}
+
+func (r fakeVariantsRepository) GetVariantClicks(ctx context.Context, urlId int) ([]entity.VariantClicks, error) {
+	return r.clicks, nil
+}
+
+func TestClickServiceVariantClicks(t *testing.T) {
+	t.Parallel()
+
+	repo := fakeVariantsRepository{
+		variants: []entity.UrlVariant{
+			{Id: 3, Url: "https://example.com/c", Weight: 50},
+			{Id: 4, Url: "https://example.com/d", Weight: 50},
+		},
+		clicks: []entity.VariantClicks{
+			{VariantId: 1, Url: "https://example.com/a", Weight: 70, Archived: true, Clicks: 7, UniqueVisitors: 5},
+			{VariantId: 3, Url: "https://example.com/c", Weight: 50, Clicks: 2, UniqueVisitors: 2},
+		},
+	}
+	s := NewClickService(repo, nil, repo, "salt")
+
+	clicks, err := s.variantClicks(context.Background(), 1)
+	require.NoError(t, err)
+
+	require.Equal(t, []entity.VariantClicks{
+		{VariantId: 3, Url: "https://example.com/c", Weight: 50, Clicks: 2, UniqueVisitors: 2},
+		{VariantId: 4, Url: "https://example.com/d", Weight: 50},
+		{VariantId: 1, Url: "https://example.com/a", Weight: 70, Archived: true, Clicks: 7, UniqueVisitors: 5},
+	}, clicks, "clicks of replaced variants stay attributed to them")
+}
+
+func TestStatsPeriod(t *testing.T) {
+	day := func(value string) time.Time {
+		res, err := time.Parse(time.DateOnly, value)
+		require.NoError(t, err)
+		return res
+	}
+
+	tests := []struct {
+		name    string
+		from    time.Time
+		to      time.Time
+		wantErr error
+	}{
+		{name: "one day", from: day("2024-01-01"), to: day("2024-01-01")},
+		{name: "leap year", from: day("2024-01-01"), to: day("2024-12-31")},
+		{name: "longer than max", from: day("2024-01-01"), to: day("2025-01-01"), wantErr: ErrInvalidStatsPeriod},
+		{name: "from the beginning of time", from: day("1000-01-01"), to: day("2024-01-01"), wantErr: ErrInvalidStatsPeriod},
+		{name: "from after to", from: day("2024-01-02"), to: day("2024-01-01"), wantErr: ErrInvalidStatsPeriod},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			from, to, err := statsPeriod(tc.from, tc.to)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.from, from)
+			require.Equal(t, tc.to.Add(24*time.Hour), to)
+		})
+	}
+}
@@ -1,9 +1,73 @@
 package services
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
 
 var (
 	ErrAliasAlreadyExists = errors.New("alias already exists")
 	ErrURLNotFound        = errors.New("url not found")
-	ErrUserHasNoUrls      = errors.New("user has no urls")
+	ErrURLExpired         = errors.New("url expired")
+	ErrInvalidExpiration  = errors.New("invalid expiration")
+	ErrURLClicksExhausted = errors.New("url clicks limit exhausted")
+	ErrInvalidStatsPeriod = errors.New("invalid stats period")
+	ErrClickDropped       = errors.New("click dropped")
+	ErrURLVersionNotFound = errors.New("url version not found")
+	ErrInvalidListParams  = errors.New("invalid list params")
+	ErrInvalidBatchSize   = errors.New("invalid batch size")
+	ErrBatchAborted       = errors.New("batch aborted")
+
+	ErrURLPasswordRequired   = errors.New("url password required")
+	ErrWrongURLPassword      = errors.New("wrong url password")
+	ErrTooManyUnlockAttempts = errors.New("too many unlock attempts")
+
+	ErrURLNotYetActive         = errors.New("url is not yet active")
+	ErrURLNoLongerActive       = errors.New("url is no longer active")
+	ErrInvalidActivationWindow = errors.New("invalid activation window")
+	ErrInvalidRedirectCode     = errors.New("invalid redirect code")
+	ErrInvalidQueryConflict    = errors.New("invalid query conflict rule")
+
+	ErrUtmTemplateNotFound = errors.New("utm template not found")
+	ErrUtmTemplateExists   = errors.New("utm template already exists")
+	ErrEmptyUtmTemplate    = errors.New("empty utm template")
+
+	ErrEmptyTargetingRule    = errors.New("targeting rule has no conditions")
+	ErrTooManyTargetingRules = errors.New("too many targeting rules")
+
+	ErrInvalidURLVariants = errors.New("invalid url variants")
+
+	ErrURLInterstitial = errors.New("url shows interstitial page")
 )
+
+// InactiveURLError is returned for urls outside of their activation window. Err is ErrURLNotYetActive
+// or ErrURLNoLongerActive, other fields help to show a placeholder instead of the url.
+type InactiveURLError struct {
+	Err         error
+	ActiveFrom  *time.Time
+	FallbackURL string
+}
+
+func (e *InactiveURLError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InactiveURLError) Unwrap() error {
+	return e.Err
+}
+
+// InterstitialError is returned for urls that show interstitial page before redirect. URL is the url
+// to show on the page.
+type InterstitialError struct {
+	URL *entity.Url
+}
+
+func (e *InterstitialError) Error() string {
+	return ErrURLInterstitial.Error()
+}
+
+func (e *InterstitialError) Unwrap() error {
+	return ErrURLInterstitial
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"sort"
@@ -15,18 +17,30 @@ import (
 
 var (
 	ErrTokenExpired = errors.New("token expired")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented.
+	// The whole family of the token is revoked then, because the token has been probably stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
 )
 
 type refreshSessionRepository interface {
 	CreateRefreshSession(ctx context.Context, refreshSession *entity.RefreshSession) error
-	DeleteRefreshSession(ctx context.Context, token string) error
-	UpdateRefreshSession(ctx context.Context, refreshSession *entity.RefreshSession) error
-	GetRefreshSession(ctx context.Context, refreshToken string) (*entity.RefreshSession, error)
+	RotateRefreshSession(ctx context.Context, sessionId int, child *entity.RefreshSession) error
+	DeleteRefreshSession(ctx context.Context, tokenHash string) error
+	DeleteRefreshSessionFamily(ctx context.Context, familyId string) error
+	DeleteUserRefreshSession(ctx context.Context, userId int, sessionId int) error
+	DeleteUserRefreshSessions(ctx context.Context, userId int) error
+	DeleteExpiredRefreshSessionFamilies(ctx context.Context, before time.Time) (int64, error)
+	GetRefreshSession(ctx context.Context, refreshTokenHash string) (*entity.RefreshSession, error)
 	GetUserRefreshSessions(ctx context.Context, userId int) ([]entity.RefreshSession, error)
 }
 
+type securityEventRepository interface {
+	CreateSecurityEvent(ctx context.Context, event *entity.SecurityEvent) error
+}
+
 type RefreshSessionService struct {
 	refreshSessionRepo refreshSessionRepository
+	securityEventRepo  securityEventRepository
 
 	tokenManager tokenManager.TokenManager
 
@@ -36,19 +50,27 @@ type RefreshSessionService struct {
 
 func NewRefreshSessionService(
 	refreshSessionRepo refreshSessionRepository,
+	securityEventRepo securityEventRepository,
 	tokenManager tokenManager.TokenManager,
 	accessTokenTTL time.Duration,
 	refreshTokenTTL time.Duration,
 ) *RefreshSessionService {
 	return &RefreshSessionService{
 		refreshSessionRepo: refreshSessionRepo,
+		securityEventRepo:  securityEventRepo,
 		tokenManager:       tokenManager,
 		accessTokenTTL:     accessTokenTTL,
 		refreshTokenTTL:    refreshTokenTTL,
 	}
 }
 
-func (s *RefreshSessionService) CreateRefreshSession(ctx context.Context, userId int) (*tokenManager.Tokens, error) {
+// SessionClient describes the client that gets the session tokens.
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+func (s *RefreshSessionService) CreateRefreshSession(ctx context.Context, userId int, client SessionClient) (*tokenManager.Tokens, error) {
 	const op = "services.refresh_session.CreateRefreshSession"
 
 	tokens, err := s.tokenManager.CreateTokensPair(strconv.Itoa(userId), s.accessTokenTTL)
@@ -56,10 +78,18 @@ func (s *RefreshSessionService) CreateRefreshSession(ctx context.Context, userId
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	familyId, err := newFamilyId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	session := entity.RefreshSession{
-		UserId:       userId,
-		RefreshToken: tokens.RefreshToken,
-		ExpiresIn:    time.Now().Add(s.refreshTokenTTL),
+		UserId:           userId,
+		RefreshTokenHash: tokenManager.HashRefreshToken(tokens.RefreshToken),
+		ExpiresIn:        time.Now().Add(s.refreshTokenTTL),
+		FamilyId:         familyId,
+		UserAgent:        client.UserAgent,
+		IP:               client.IP,
 	}
 	err = s.refreshSessionRepo.CreateRefreshSession(ctx, &session)
 	if err != nil {
@@ -92,7 +122,7 @@ func (s *RefreshSessionService) DeleteEarliestRefreshSession(ctx context.Context
 		return sessions[i].ExpiresIn.Before(sessions[j].ExpiresIn)
 	})
 
-	err := s.refreshSessionRepo.DeleteRefreshSession(ctx, sessions[0].RefreshToken)
+	err := s.refreshSessionRepo.DeleteRefreshSession(ctx, sessions[0].RefreshTokenHash)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -103,7 +133,19 @@ func (s *RefreshSessionService) DeleteEarliestRefreshSession(ctx context.Context
 func (s *RefreshSessionService) DeleteRefreshSession(ctx context.Context, refreshToken string) error {
 	const op = "services.refresh_session.DeleteRefreshSession"
 
-	err := s.refreshSessionRepo.DeleteRefreshSession(ctx, refreshToken)
+	err := s.refreshSessionRepo.DeleteRefreshSession(ctx, tokenManager.HashRefreshToken(refreshToken))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteUserRefreshSession revokes the user session with the id.
+func (s *RefreshSessionService) DeleteUserRefreshSession(ctx context.Context, userId int, sessionId int) error {
+	const op = "services.refresh_session.DeleteUserRefreshSession"
+
+	err := s.refreshSessionRepo.DeleteUserRefreshSession(ctx, userId, sessionId)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -111,27 +153,116 @@ func (s *RefreshSessionService) DeleteRefreshSession(ctx context.Context, refres
 	return nil
 }
 
-func (s *RefreshSessionService) ValidateRefreshSession(ctx context.Context, refreshToken string) (int, error) {
-	const op = "services.refresh_session.ValidateRefreshSession"
+// DeleteUserRefreshSessions revokes all sessions of the user.
+func (s *RefreshSessionService) DeleteUserRefreshSessions(ctx context.Context, userId int) error {
+	const op = "services.refresh_session.DeleteUserRefreshSessions"
 
-	session, err := s.refreshSessionRepo.GetRefreshSession(ctx, refreshToken)
+	err := s.refreshSessionRepo.DeleteUserRefreshSessions(ctx, userId)
 	if err != nil {
-		return -1, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = s.refreshSessionRepo.DeleteRefreshSession(ctx, session.RefreshToken)
+	return nil
+}
+
+// PurgeExpiredRefreshSessions deletes session families which newest session has expired. Such families
+// are deleted on refresh otherwise, so families of clients that never come back would be kept forever.
+func (s *RefreshSessionService) PurgeExpiredRefreshSessions(ctx context.Context) (int64, error) {
+	const op = "services.refresh_session.PurgeExpiredRefreshSessions"
+
+	deleted, err := s.refreshSessionRepo.DeleteExpiredRefreshSessionFamilies(ctx, time.Now())
 	if err != nil {
-		return -1, fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// RotateRefreshSession exchanges the refresh token for a new pair of tokens. The session is kept as rotated
+// and the new one becomes its child in the same family. Presenting a rotated token revokes the whole family
+// and records a security event, see OAuth 2.0 refresh token rotation.
+func (s *RefreshSessionService) RotateRefreshSession(ctx context.Context, refreshToken string, client SessionClient) (*tokenManager.Tokens, error) {
+	const op = "services.refresh_session.RotateRefreshSession"
+
+	session, err := s.refreshSessionRepo.GetRefreshSession(ctx, tokenManager.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session.IsRotated() {
+		return nil, s.revokeReusedFamily(ctx, session)
 	}
 
 	ok := s.isSessionExpired(session)
 	if !ok {
-		return -1, ErrTokenExpired
+		err = s.refreshSessionRepo.DeleteRefreshSessionFamily(ctx, session.FamilyId)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil, ErrTokenExpired
+	}
+
+	tokens, err := s.tokenManager.CreateTokensPair(strconv.Itoa(session.UserId), s.accessTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	child := entity.RefreshSession{
+		UserId:           session.UserId,
+		RefreshTokenHash: tokenManager.HashRefreshToken(tokens.RefreshToken),
+		ExpiresIn:        time.Now().Add(s.refreshTokenTTL),
+		FamilyId:         session.FamilyId,
+		ParentId:         &session.Id,
+		UserAgent:        client.UserAgent,
+		IP:               client.IP,
+		CreatedAt:        session.CreatedAt,
+	}
+	err = s.refreshSessionRepo.RotateRefreshSession(ctx, session.Id, &child)
+	if err != nil {
+		// the token has been rotated by a concurrent request
+		if errors.Is(err, repository.ErrRefreshSessionRotated) {
+			return nil, s.revokeReusedFamily(ctx, session)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// revokeReusedFamily deletes all sessions of the family of the reused session and records a security event.
+// It returns ErrRefreshTokenReused if revocation succeeded.
+func (s *RefreshSessionService) revokeReusedFamily(ctx context.Context, session *entity.RefreshSession) error {
+	const op = "services.refresh_session.revokeReusedFamily"
+
+	err := s.refreshSessionRepo.DeleteRefreshSessionFamily(ctx, session.FamilyId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return session.UserId, nil
+	err = s.securityEventRepo.CreateSecurityEvent(ctx, &entity.SecurityEvent{
+		UserId:  session.UserId,
+		Type:    entity.SecurityEventRefreshTokenReuse,
+		Details: fmt.Sprintf("session %d of family %s is reused, the family is revoked", session.Id, session.FamilyId),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, ErrRefreshTokenReused)
 }
 
 func (s *RefreshSessionService) isSessionExpired(session *entity.RefreshSession) bool {
 	return session.ExpiresIn.After(time.Now())
 }
+
+// newFamilyId returns a random id of a new refresh session family.
+func newFamilyId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	tokenManager "github.com/4aykovski/url_shortener/pkg/manager/token"
+	"github.com/4aykovski/url_shortener/pkg/revocation"
+)
+
+type tokenRevocationRepository interface {
+	CreateRevokedToken(ctx context.Context, token *entity.RevokedToken) error
+	SaveUserTokenRevocation(ctx context.Context, revocation *entity.UserTokenRevocation) error
+	GetRevokedTokens(ctx context.Context) ([]entity.RevokedToken, error)
+	GetUserTokenRevocations(ctx context.Context) ([]entity.UserTokenRevocation, error)
+	DeleteExpiredTokenRevocations(ctx context.Context) error
+}
+
+type revocationStore interface {
+	RevokeToken(jti string, expiresAt time.Time)
+	RevokeUser(userId string, before time.Time, expiresAt time.Time)
+	IsRevoked(jti string, userId string, issuedAt time.Time) bool
+	Cleanup()
+}
+
+// TokenRevocationService revokes access tokens before they expire. Revocations are checked in memory
+// on every request and persisted in Postgres, so they survive restarts and reach other instances on Sync.
+type TokenRevocationService struct {
+	repo  tokenRevocationRepository
+	store revocationStore
+
+	tokenManager tokenManager.TokenManager
+
+	accessTokenTTL time.Duration
+}
+
+func NewTokenRevocationService(
+	repo tokenRevocationRepository,
+	store revocationStore,
+	tokenManager tokenManager.TokenManager,
+	accessTokenTTL time.Duration,
+) *TokenRevocationService {
+	return &TokenRevocationService{
+		repo:           repo,
+		store:          store,
+		tokenManager:   tokenManager,
+		accessTokenTTL: accessTokenTTL,
+	}
+}
+
+// RevokeAccessToken revokes the access token until it expires. Invalid and expired tokens are rejected
+// anyway and tokens without jti can't be revoked one by one, so they're skipped.
+func (s *TokenRevocationService) RevokeAccessToken(ctx context.Context, accessToken string) error {
+	const op = "services.token_revocation.RevokeAccessToken"
+
+	claims, err := s.tokenManager.Parse(accessToken)
+	if err != nil {
+		return nil
+	}
+
+	jti, _ := claims["jti"].(string)
+	userId, _ := claims["user_id"].(string)
+	expiresAt, err := claims.GetExpirationTime()
+	if jti == "" || err != nil || expiresAt == nil {
+		return nil
+	}
+
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.CreateRevokedToken(ctx, &entity.RevokedToken{
+		Jti:       jti,
+		UserId:    id,
+		ExpiresAt: expiresAt.Time,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.store.RevokeToken(jti, expiresAt.Time)
+
+	return nil
+}
+
+// RevokeUserTokens revokes all access tokens issued to the user so far. It returns at the cutoff
+// of the revocation, up to a second later, so tokens issued after it returns aren't revoked.
+func (s *TokenRevocationService) RevokeUserTokens(ctx context.Context, userId int) error {
+	const op = "services.token_revocation.RevokeUserTokens"
+
+	cutoff := revocation.Cutoff(time.Now())
+	userRevocation := entity.UserTokenRevocation{
+		UserId:        userId,
+		RevokedBefore: cutoff,
+		// tokens issued before the cutoff expire until then
+		ExpiresAt: cutoff.Add(s.accessTokenTTL),
+	}
+
+	err := s.repo.SaveUserTokenRevocation(ctx, &userRevocation)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.store.RevokeUser(strconv.Itoa(userId), userRevocation.RevokedBefore, userRevocation.ExpiresAt)
+
+	timer := time.NewTimer(time.Until(cutoff))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	case <-timer.C:
+	}
+
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token is revoked. issuedAt is zero for tokens without iat.
+func (s *TokenRevocationService) IsTokenRevoked(jti string, userId string, issuedAt time.Time) bool {
+	return s.store.IsRevoked(jti, userId, issuedAt)
+}
+
+// Sync loads revocations made by other instances from Postgres and drops the expired ones.
+func (s *TokenRevocationService) Sync(ctx context.Context) error {
+	const op = "services.token_revocation.Sync"
+
+	err := s.repo.DeleteExpiredTokenRevocations(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := s.repo.GetRevokedTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revocations, err := s.repo.GetUserTokenRevocations(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, token := range tokens {
+		s.store.RevokeToken(token.Jti, token.ExpiresAt)
+	}
+
+	for _, revocation := range revocations {
+		s.store.RevokeUser(strconv.Itoa(revocation.UserId), revocation.RevokedBefore, revocation.ExpiresAt)
+	}
+
+	s.store.Cleanup()
+
+	return nil
+}
@@ -4,68 +4,676 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/4aykovski/url_shortener/internal/adapters/repository"
 	"github.com/4aykovski/url_shortener/internal/entity"
-	"github.com/4aykovski/url_shortener/pkg/random"
+	"github.com/4aykovski/url_shortener/pkg/utm"
 )
 
 type urlRepository interface {
-	SaveURL(ctx context.Context, urlToSave string, alias string, userId int) error
-	GetURL(ctx context.Context, alias string) (string, error)
-	GetURLsByUserId(ctx context.Context, userId int) ([]entity.Url, error)
+	SaveURL(ctx context.Context, url *entity.Url) error
+	SaveURLs(ctx context.Context, urls []*entity.Url) error
+	GetURL(ctx context.Context, alias string) (*entity.Url, error)
+	IncrementClicks(ctx context.Context, id int) error
+	UpdateURL(ctx context.Context, url *entity.Url) error
+	GetURLVersions(ctx context.Context, urlId int) ([]entity.UrlVersion, error)
+	GetURLVersion(ctx context.Context, urlId int, version int) (*entity.UrlVersion, error)
+	ListURLs(ctx context.Context, params repository.ListURLsParams) ([]entity.Url, error)
+	IterateUserURLs(ctx context.Context, userId int, fn func(url *entity.Url) error) error
 	DeleteURL(ctx context.Context, alias string, userId int) error
+	DeleteExpiredURLs(ctx context.Context, before time.Time) (int64, error)
+	GetTargetingRules(ctx context.Context, urlId int) ([]entity.TargetingRule, error)
+	ReplaceTargetingRules(ctx context.Context, urlId int, rules []entity.TargetingRule) error
+	GetURLVariants(ctx context.Context, urlId int) ([]entity.UrlVariant, error)
+	ReplaceURLVariants(ctx context.Context, url *entity.Url) error
+}
+
+type utmTemplateGetter interface {
+	GetUtmTemplate(ctx context.Context, userId int, name string) (*entity.UtmTemplate, error)
+}
+
+type aliasGenerator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+type aliasValidator interface {
+	Validate(alias string) error
+}
+
+type attemptLimiter interface {
+	Attempt(key string) bool
+	Reset(key string)
 }
 
 type UrlService struct {
-	urlRepository urlRepository
+	urlRepository   urlRepository
+	utmTemplates    utmTemplateGetter
+	aliasGenerator  aliasGenerator
+	aliasPolicy     aliasValidator
+	maxAliasRetries int
+
+	hasher        passHasher
+	unlockLimiter attemptLimiter
+	// aliasUnlockLimiter limits attempts of the url from all ips, so changing ip doesn't give more guesses
+	aliasUnlockLimiter attemptLimiter
+	unlockSecret       []byte
+	unlockTTL          time.Duration
 }
 
-func NewUrlService(urlRepository urlRepository) *UrlService {
+// NewUrlService creates url service. Generated aliases colliding with existing ones are regenerated
+// up to maxAliasRetries times, ones not allowed by aliasPolicy are regenerated too. Unlock tokens of password-protected urls are signed with unlockSecret
+// and live for unlockTTL, wrong passwords are limited per url and ip by unlockLimiter and per url
+// by aliasUnlockLimiter.
+func NewUrlService(
+	urlRepository urlRepository,
+	utmTemplates utmTemplateGetter,
+	aliasGenerator aliasGenerator,
+	aliasPolicy aliasValidator,
+	maxAliasRetries int,
+	hasher passHasher,
+	unlockLimiter attemptLimiter,
+	aliasUnlockLimiter attemptLimiter,
+	unlockSecret string,
+	unlockTTL time.Duration,
+) *UrlService {
 	return &UrlService{
-		urlRepository: urlRepository,
+		urlRepository:      urlRepository,
+		utmTemplates:       utmTemplates,
+		aliasGenerator:     aliasGenerator,
+		aliasPolicy:        aliasPolicy,
+		maxAliasRetries:    maxAliasRetries,
+		hasher:             hasher,
+		unlockLimiter:      unlockLimiter,
+		aliasUnlockLimiter: aliasUnlockLimiter,
+		unlockSecret:       []byte(unlockSecret),
+		unlockTTL:          unlockTTL,
 	}
 }
 
-const aliasLength = 6
-
 type SaveURLInput struct {
 	URL    string
 	Alias  string
 	UserId int
+	// ExpiresAt and TTL are mutually exclusive. If both are empty the url never expires.
+	ExpiresAt *time.Time
+	TTL       time.Duration
+	// MaxClicks limits the number of redirects. Nil means unlimited.
+	MaxClicks *int
+	// Password protects the url, empty means no password.
+	Password string
+	// ActiveFrom and ActiveUntil limit the time the url works. FallbackURL is used outside of this window.
+	ActiveFrom  *time.Time
+	ActiveUntil *time.Time
+	FallbackURL string
+	// RedirectCode is 301, 302, 307 or 308. Zero means entity.DefaultRedirectCode.
+	RedirectCode int
+	// ForwardQuery merges query of the short url into the destination, conflicts are resolved
+	// with QueryConflict rule, entity.QueryConflictKeep by default.
+	ForwardQuery  bool
+	QueryConflict string
+	// Prefix appends the rest of the short url path to the destination.
+	Prefix bool
+	// UTM params are merged into URL. Params missing in UTM are taken from the user template
+	// named UTMTemplate if it's set.
+	UTM         utm.Params
+	UTMTemplate string
+	// Variants split visitors between several destinations, URL defaults to the first of them.
+	Variants []UrlVariantInput
+	// Title and Description are shown on the preview page. Interstitial urls show it before every redirect.
+	Title        string
+	Description  string
+	Interstitial bool
 }
 
 func (s *UrlService) SaveURL(ctx context.Context, input SaveURLInput) (string, error) {
+	url, err := s.newURL(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = s.urlRepository.SaveURL(ctx, url)
+		if err == nil {
+			return url.Alias, nil
+		}
+
+		if !errors.Is(err, repository.ErrUrlExists) {
+			return "", fmt.Errorf("failed to save url: %w", err)
+		}
+
+		if input.Alias != "" || attempt >= s.maxAliasRetries {
+			return "", fmt.Errorf("alias already exists: %w", ErrAliasAlreadyExists)
+		}
+
+		if url.Alias, err = s.generateAlias(ctx); err != nil {
+			return "", err
+		}
+	}
+}
+
+// MaxBatchSize bounds the number of urls saved by SaveURLs. It's kept low because every
+// password-protected url of the batch is hashed.
+const MaxBatchSize = 100
+
+type SaveURLsInput struct {
+	Urls []SaveURLInput
+	// Atomic mode saves either all urls or none of them. Otherwise every url is saved independently.
+	Atomic bool
+}
+
+type SaveURLResult struct {
+	Alias string
+	Err   error
+}
+
+// SaveURLs saves a batch of urls. It returns a result for every input url in the same order.
+// In atomic mode urls that weren't saved because of another url failure have ErrBatchAborted error.
+func (s *UrlService) SaveURLs(ctx context.Context, input SaveURLsInput) ([]SaveURLResult, error) {
+	if len(input.Urls) == 0 || len(input.Urls) > MaxBatchSize {
+		return nil, fmt.Errorf("batch size must be between 1 and %d: %w", MaxBatchSize, ErrInvalidBatchSize)
+	}
+
+	if !input.Atomic {
+		return s.saveEach(ctx, input.Urls), nil
+	}
+
+	results := make([]SaveURLResult, len(input.Urls))
+
+	urls := make([]*entity.Url, len(input.Urls))
+	failed := false
+	for i, urlInput := range input.Urls {
+		url, err := s.newURL(ctx, urlInput)
+		if err != nil {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+
+		urls[i] = url
+		results[i].Alias = url.Alias
+	}
+
+	for attempt := 0; !failed; attempt++ {
+		err := s.urlRepository.SaveURLs(ctx, urls)
+		if err == nil {
+			return results, nil
+		}
+
+		var batchErr *repository.BatchError
+		if !errors.As(err, &batchErr) || !errors.Is(batchErr.Err, repository.ErrUrlExists) {
+			return nil, fmt.Errorf("failed to save urls: %w", err)
+		}
+
+		// the whole batch is rolled back, so it's retried with a new alias for the collided url
+		i := batchErr.Index
+		if input.Urls[i].Alias == "" && attempt < s.maxAliasRetries {
+			alias, err := s.generateAlias(ctx)
+			if err == nil {
+				urls[i].Alias = alias
+				results[i].Alias = alias
+				continue
+			}
+
+			results[i].Err = err
+		} else {
+			results[i].Err = fmt.Errorf("alias already exists: %w", ErrAliasAlreadyExists)
+		}
+
+		failed = true
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = SaveURLResult{Err: ErrBatchAborted}
+		}
+	}
+
+	return results, nil
+}
+
+// ImportURLs saves every url independently, so conflicts are reported per url. Unlike SaveURLs it isn't
+// limited in size, the caller is responsible for bounding the input.
+func (s *UrlService) ImportURLs(ctx context.Context, urls []SaveURLInput) []SaveURLResult {
+	return s.saveEach(ctx, urls)
+}
+
+type ExportUserURLsInput struct {
+	UserId int
+}
+
+// ExportUserURLs calls fn for every url of the user. Urls are streamed from the repository,
+// so fn is expected to write them out instead of collecting.
+func (s *UrlService) ExportUserURLs(ctx context.Context, input ExportUserURLsInput, fn func(url *entity.Url) error) error {
+	if err := s.urlRepository.IterateUserURLs(ctx, input.UserId, fn); err != nil {
+		return fmt.Errorf("failed to export user urls: %w", err)
+	}
+
+	return nil
+}
+
+func (s *UrlService) saveEach(ctx context.Context, urls []SaveURLInput) []SaveURLResult {
+	results := make([]SaveURLResult, len(urls))
+	for i, urlInput := range urls {
+		results[i].Alias, results[i].Err = s.SaveURL(ctx, urlInput)
+	}
+
+	return results
+}
+
+// newURL builds the url to save from input, generating alias and resolving expiration time.
+func (s *UrlService) newURL(ctx context.Context, input SaveURLInput) (*entity.Url, error) {
 	alias := input.Alias
 	if alias == "" {
-		alias = random.NewRandomString(aliasLength)
+		var err error
+		if alias, err = s.generateAlias(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := s.urlRepository.SaveURL(ctx, input.URL, alias, input.UserId); err != nil {
-		if errors.Is(err, repository.ErrUrlExists) {
-			return "", fmt.Errorf("alias already exists: %w", ErrAliasAlreadyExists)
+	expiresAt, err := s.expirationTime(input.ExpiresAt, input.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	activeFrom, activeUntil := utcTime(input.ActiveFrom), utcTime(input.ActiveUntil)
+	if err = validateActivationWindow(activeFrom, activeUntil, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	redirectCode := input.RedirectCode
+	if redirectCode == 0 {
+		redirectCode = entity.DefaultRedirectCode
+	}
+
+	if !validRedirectCode(redirectCode) {
+		return nil, fmt.Errorf("redirect code %d isn't supported: %w", redirectCode, ErrInvalidRedirectCode)
+	}
+
+	queryConflict := input.QueryConflict
+	if queryConflict == "" {
+		queryConflict = entity.QueryConflictKeep
+	}
+
+	if !validQueryConflict(queryConflict) {
+		return nil, fmt.Errorf("query conflict rule %q isn't supported: %w", queryConflict, ErrInvalidQueryConflict)
+	}
+
+	if err = validateVariants(input.Variants); err != nil {
+		return nil, err
+	}
+
+	if input.URL == "" {
+		if len(input.Variants) == 0 {
+			return nil, fmt.Errorf("url has neither destination nor variants: %w", ErrInvalidURLVariants)
+		}
+
+		input.URL = input.Variants[0].URL
+	}
+
+	params, err := s.utmParams(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := utmDestination(input.URL, params)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := newVariants(input.Variants, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var passwordHash string
+	if input.Password != "" {
+		if passwordHash, err = s.hasher.Hash(input.Password); err != nil {
+			return nil, fmt.Errorf("failed to hash url password: %w", err)
+		}
+	}
+
+	return &entity.Url{
+		Url:          destination,
+		Alias:        alias,
+		UserId:       input.UserId,
+		ExpiresAt:    expiresAt,
+		MaxClicks:    input.MaxClicks,
+		PasswordHash: passwordHash,
+		ActiveFrom:   activeFrom,
+		ActiveUntil:  activeUntil,
+		FallbackUrl:  input.FallbackURL,
+		RedirectCode: redirectCode,
+
+		ForwardQuery:  input.ForwardQuery,
+		QueryConflict: queryConflict,
+		Prefix:        input.Prefix,
+
+		Title:        input.Title,
+		Description:  input.Description,
+		Interstitial: input.Interstitial,
+
+		Variants: variants,
+	}, nil
+}
+
+// utmParams returns utm params of the input completed with params of its template.
+func (s *UrlService) utmParams(ctx context.Context, input SaveURLInput) (utm.Params, error) {
+	params := input.UTM
+
+	if input.UTMTemplate != "" {
+		template, err := s.utmTemplates.GetUtmTemplate(ctx, input.UserId, input.UTMTemplate)
+		if err != nil {
+			if errors.Is(err, repository.ErrUtmTemplateNotFound) {
+				return utm.Params{}, fmt.Errorf("utm template not found: %w", ErrUtmTemplateNotFound)
+			}
+
+			return utm.Params{}, fmt.Errorf("failed to get utm template: %w", err)
 		}
 
-		return "", fmt.Errorf("failed to save url: %w", err)
+		params = params.Or(utmTemplateParams(template))
+	}
+
+	return params, nil
+}
+
+// utmDestination returns the destination with utm params.
+func utmDestination(destination string, params utm.Params) (string, error) {
+	destination, err := utm.Merge(destination, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to add utm params: %w", err)
 	}
 
-	return alias, nil
+	return destination, nil
+}
+
+// maxAliasPolicyRetries bounds regenerating aliases that aren't allowed by the alias policy, e.g. random
+// ones containing a blocked word. If the generator never fits the policy, saving fails instead of looping.
+const maxAliasPolicyRetries = 10
+
+// generateAlias generates an alias allowed by the alias policy, the same rules apply to custom aliases.
+func (s *UrlService) generateAlias(ctx context.Context) (string, error) {
+	for attempt := 0; ; attempt++ {
+		alias, err := s.aliasGenerator.Generate(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate alias: %w", err)
+		}
+
+		err = s.aliasPolicy.Validate(alias)
+		if err == nil {
+			return alias, nil
+		}
+
+		if attempt >= maxAliasPolicyRetries {
+			return "", fmt.Errorf("failed to generate alias: %w", err)
+		}
+	}
 }
 
 type GetURLInput struct {
 	Alias string
+	// UnlockToken is required to get password-protected urls, it's issued by UnlockURL.
+	UnlockToken string
+	// Path is the rest of the short url path after alias. Only prefix urls can be got with path.
+	Path string
+	// SkipInterstitial is set when the client has confirmed the redirect on the interstitial page.
+	SkipInterstitial bool
+}
+
+// GetURL returns the url to redirect to and counts the click. Interstitial urls are returned
+// in *InterstitialError without counting the click unless the input skips interstitial.
+func (s *UrlService) GetURL(ctx context.Context, input GetURLInput) (*entity.Url, error) {
+	url, err := s.availableURL(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if url.Interstitial && !input.SkipInterstitial {
+		return nil, &InterstitialError{URL: url}
+	}
+
+	if err = s.urlRepository.IncrementClicks(ctx, url.Id); err != nil {
+		if errors.Is(err, repository.ErrURLClicksExhausted) {
+			return nil, fmt.Errorf("url clicks limit exhausted: %w", ErrURLClicksExhausted)
+		}
+
+		return nil, fmt.Errorf("failed to increment url clicks: %w", err)
+	}
+
+	if url.TargetingRules, err = s.urlRepository.GetTargetingRules(ctx, url.Id); err != nil {
+		return nil, fmt.Errorf("failed to get url targeting rules: %w", err)
+	}
+
+	if url.Variants, err = s.urlRepository.GetURLVariants(ctx, url.Id); err != nil {
+		return nil, fmt.Errorf("failed to get url variants: %w", err)
+	}
+
+	return url, nil
 }
 
-func (s *UrlService) GetURL(ctx context.Context, input GetURLInput) (string, error) {
-	resURL, err := s.urlRepository.GetURL(ctx, input.Alias)
+// availableURL returns the url if it can be followed now: it isn't expired, is within its activation window
+// and is unlocked if it's protected.
+func (s *UrlService) availableURL(ctx context.Context, input GetURLInput) (*entity.Url, error) {
+	url, err := s.urlRepository.GetURL(ctx, input.Alias)
 	if err != nil {
 		if errors.Is(err, repository.ErrURLNotFound) {
-			return "", fmt.Errorf("url not found: %w", ErrURLNotFound)
+			return nil, fmt.Errorf("url not found: %w", ErrURLNotFound)
 		}
 
-		return "", fmt.Errorf("failed to get url: %w", err)
+		return nil, fmt.Errorf("failed to get url: %w", err)
+	}
+
+	if input.Path != "" && !url.Prefix {
+		return nil, fmt.Errorf("url isn't prefix: %w", ErrURLNotFound)
 	}
-	return resURL, nil
+
+	now := time.Now().UTC()
+
+	if url.IsExpired(now) {
+		return nil, fmt.Errorf("url expired: %w", ErrURLExpired)
+	}
+
+	if url.IsNotYetActive(now) {
+		return nil, &InactiveURLError{Err: ErrURLNotYetActive, ActiveFrom: url.ActiveFrom, FallbackURL: url.FallbackUrl}
+	}
+
+	if url.IsNoLongerActive(now) {
+		return nil, &InactiveURLError{Err: ErrURLNoLongerActive, FallbackURL: url.FallbackUrl}
+	}
+
+	if url.IsProtected() && !s.validUnlockToken(url, input.UnlockToken, now) {
+		return nil, fmt.Errorf("url password required: %w", ErrURLPasswordRequired)
+	}
+
+	return url, nil
+}
+
+type UpdateURLInput struct {
+	Alias  string
+	UserId int
+	// Nil fields are left unchanged.
+	URL         *string
+	ExpiresAt   *time.Time
+	TTL         time.Duration
+	MaxClicks   *int
+	ActiveFrom  *time.Time
+	ActiveUntil *time.Time
+	FallbackURL *string
+	// RedirectCode is 301, 302, 307 or 308.
+	RedirectCode  *int
+	ForwardQuery  *bool
+	QueryConflict *string
+	Prefix        *bool
+	Title         *string
+	Description   *string
+	Interstitial  *bool
+	// ClearExpiration and ClearMaxClicks remove expiration and clicks limit of the url.
+	ClearExpiration bool
+	ClearMaxClicks  bool
+	// ClearActiveFrom, ClearActiveUntil and ClearFallbackURL remove activation window bounds and fallback url.
+	ClearActiveFrom  bool
+	ClearActiveUntil bool
+	ClearFallbackURL bool
+}
+
+// UpdateURL changes mutable attributes of the url. Only the owner of the url can update it.
+func (s *UrlService) UpdateURL(ctx context.Context, input UpdateURLInput) error {
+	url, err := s.getUserURL(ctx, input.Alias, input.UserId)
+	if err != nil {
+		return err
+	}
+
+	if input.URL != nil {
+		url.Url = *input.URL
+	}
+
+	switch {
+	case input.ClearExpiration:
+		if input.ExpiresAt != nil || input.TTL != 0 {
+			return fmt.Errorf("expiration is both set and cleared: %w", ErrInvalidExpiration)
+		}
+
+		url.ExpiresAt = nil
+	case input.ExpiresAt != nil || input.TTL != 0:
+		url.ExpiresAt, err = s.expirationTime(input.ExpiresAt, input.TTL)
+		if err != nil {
+			return err
+		}
+	}
+
+	if input.ClearMaxClicks {
+		url.MaxClicks = nil
+	} else if input.MaxClicks != nil {
+		url.MaxClicks = input.MaxClicks
+	}
+
+	if input.ClearActiveFrom {
+		url.ActiveFrom = nil
+	} else if input.ActiveFrom != nil {
+		url.ActiveFrom = utcTime(input.ActiveFrom)
+	}
+
+	if input.ClearActiveUntil {
+		url.ActiveUntil = nil
+	} else if input.ActiveUntil != nil {
+		url.ActiveUntil = utcTime(input.ActiveUntil)
+	}
+
+	// the window is checked only if it's changed, so urls which window has ended can still be edited
+	windowChanged := input.ClearActiveFrom || input.ActiveFrom != nil || input.ClearActiveUntil || input.ActiveUntil != nil
+	if windowChanged {
+		if err = validateActivationWindow(url.ActiveFrom, url.ActiveUntil, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+
+	if input.RedirectCode != nil {
+		if !validRedirectCode(*input.RedirectCode) {
+			return fmt.Errorf("redirect code %d isn't supported: %w", *input.RedirectCode, ErrInvalidRedirectCode)
+		}
+
+		url.RedirectCode = *input.RedirectCode
+	}
+
+	if input.QueryConflict != nil {
+		if !validQueryConflict(*input.QueryConflict) {
+			return fmt.Errorf("query conflict rule %q isn't supported: %w", *input.QueryConflict, ErrInvalidQueryConflict)
+		}
+
+		url.QueryConflict = *input.QueryConflict
+	}
+
+	if input.ForwardQuery != nil {
+		url.ForwardQuery = *input.ForwardQuery
+	}
+
+	if input.Prefix != nil {
+		url.Prefix = *input.Prefix
+	}
+
+	if input.Title != nil {
+		url.Title = *input.Title
+	}
+
+	if input.Description != nil {
+		url.Description = *input.Description
+	}
+
+	if input.Interstitial != nil {
+		url.Interstitial = *input.Interstitial
+	}
+
+	if input.ClearFallbackURL {
+		url.FallbackUrl = ""
+	} else if input.FallbackURL != nil {
+		url.FallbackUrl = *input.FallbackURL
+	}
+
+	if err = s.urlRepository.UpdateURL(ctx, url); err != nil {
+		if errors.Is(err, repository.ErrURLNotFound) {
+			return fmt.Errorf("url not found: %w", ErrURLNotFound)
+		}
+
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+
+	return nil
+}
+
+type GetURLHistoryInput struct {
+	Alias  string
+	UserId int
+}
+
+// GetURLHistory returns all destination and variants changes of the url in ascending order of versions.
+func (s *UrlService) GetURLHistory(ctx context.Context, input GetURLHistoryInput) ([]entity.UrlVersion, error) {
+	url, err := s.getUserURL(ctx, input.Alias, input.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.urlRepository.GetURLVersions(ctx, url.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+type RollbackURLInput struct {
+	Alias   string
+	UserId  int
+	Version int
+}
+
+// RollbackURL restores destination of the given version. The rollback itself is recorded as a new version.
+// Versions of variants changes keep the destination they had, variants aren't restored.
+func (s *UrlService) RollbackURL(ctx context.Context, input RollbackURLInput) error {
+	url, err := s.getUserURL(ctx, input.Alias, input.UserId)
+	if err != nil {
+		return err
+	}
+
+	version, err := s.urlRepository.GetURLVersion(ctx, url.Id, input.Version)
+	if err != nil {
+		if errors.Is(err, repository.ErrURLVersionNotFound) {
+			return fmt.Errorf("url version not found: %w", ErrURLVersionNotFound)
+		}
+
+		return fmt.Errorf("failed to get url version: %w", err)
+	}
+
+	url.Url = version.NewUrl
+	if err = s.urlRepository.UpdateURL(ctx, url); err != nil {
+		if errors.Is(err, repository.ErrURLNotFound) {
+			return fmt.Errorf("url not found: %w", ErrURLNotFound)
+		}
+
+		return fmt.Errorf("failed to rollback url: %w", err)
+	}
+
+	return nil
 }
 
 type DeleteURLInput struct {
@@ -86,29 +694,179 @@ func (s *UrlService) DeleteURL(ctx context.Context, input DeleteURLInput) error
 	return nil
 }
 
+// PurgeExpiredURLs deletes urls that have been expired for longer than retention.
+func (s *UrlService) PurgeExpiredURLs(ctx context.Context, retention time.Duration) (int64, error) {
+	deleted, err := s.urlRepository.DeleteExpiredURLs(ctx, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired urls: %w", err)
+	}
+
+	return deleted, nil
+}
+
+const (
+	defaultUrlsPageSize = 20
+	maxUrlsPageSize     = 100
+)
+
 type GetAllUserUrlsInput struct {
 	UserId int
+	// Search filters urls which alias or destination contains the substring.
+	Search string
+	// SortBy is either "created_at" (default) or "clicks".
+	SortBy string
+	// Order is either "desc" (default) or "asc".
+	Order string
+	Limit int
+	// Cursor is NextCursor of the previous page. Empty cursor means the first page.
+	Cursor string
 }
 
 type GetAllUserUrlsOutput struct {
-	Urls map[string]string
+	Urls []entity.Url
+	// NextCursor is empty if there are no more urls.
+	NextCursor string
 }
 
+// GetAllUserUrls returns a page of user urls.
 func (s *UrlService) GetAllUserUrls(ctx context.Context, input GetAllUserUrlsInput) (GetAllUserUrlsOutput, error) {
-	urls, err := s.urlRepository.GetURLsByUserId(ctx, input.UserId)
-	if err != nil {
-		if errors.Is(err, repository.ErrURLsNotFound) {
-			return GetAllUserUrlsOutput{}, fmt.Errorf("user has no urls: %w", ErrUserHasNoUrls)
+	params := repository.ListURLsParams{
+		UserId:     input.UserId,
+		Search:     input.Search,
+		SortBy:     repository.SortByCreatedAt,
+		Descending: true,
+		Limit:      defaultUrlsPageSize,
+	}
+
+	switch input.SortBy {
+	case "", string(repository.SortByCreatedAt):
+	case string(repository.SortByClicks):
+		params.SortBy = repository.SortByClicks
+	default:
+		return GetAllUserUrlsOutput{}, fmt.Errorf("unknown sort field %q: %w", input.SortBy, ErrInvalidListParams)
+	}
+
+	switch input.Order {
+	case "", "desc":
+	case "asc":
+		params.Descending = false
+	default:
+		return GetAllUserUrlsOutput{}, fmt.Errorf("unknown order %q: %w", input.Order, ErrInvalidListParams)
+	}
+
+	if input.Limit < 0 || input.Limit > maxUrlsPageSize {
+		return GetAllUserUrlsOutput{}, fmt.Errorf("limit is out of range: %w", ErrInvalidListParams)
+	}
+	if input.Limit != 0 {
+		params.Limit = input.Limit
+	}
+
+	if input.Cursor != "" {
+		cursor, err := decodeUrlsCursor(input.Cursor, params)
+		if err != nil {
+			return GetAllUserUrlsOutput{}, err
 		}
+		params.After = cursor
+	}
+
+	// one more url is requested to find out whether there is a next page
+	pageSize := params.Limit
+	params.Limit++
 
+	urls, err := s.urlRepository.ListURLs(ctx, params)
+	if err != nil {
 		return GetAllUserUrlsOutput{}, fmt.Errorf("failed to get all user urls: %w", err)
 	}
 
 	var output GetAllUserUrlsOutput
-	output.Urls = make(map[string]string)
-	for _, url := range urls {
-		output.Urls[url.Alias] = url.Url
+	if len(urls) > pageSize {
+		urls = urls[:pageSize]
+		output.NextCursor = encodeUrlsCursor(urls[len(urls)-1], params)
 	}
+	output.Urls = urls
 
 	return output, nil
 }
+
+// expirationTime resolves absolute expiration time of the url from either expiresAt or ttl.
+func (s *UrlService) expirationTime(expiresAt *time.Time, ttl time.Duration) (*time.Time, error) {
+	now := time.Now().UTC()
+
+	switch {
+	case expiresAt != nil && ttl != 0:
+		return nil, fmt.Errorf("both expires_at and ttl are specified: %w", ErrInvalidExpiration)
+	case expiresAt != nil:
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("expires_at is in the past: %w", ErrInvalidExpiration)
+		}
+
+		t := expiresAt.UTC()
+		return &t, nil
+	case ttl < 0:
+		return nil, fmt.Errorf("ttl is negative: %w", ErrInvalidExpiration)
+	case ttl > 0:
+		t := now.Add(ttl)
+		return &t, nil
+	}
+
+	return nil, nil
+}
+
+// validateActivationWindow checks that the window isn't empty and hasn't ended by now,
+// otherwise the url would never work. Both bounds are optional.
+func validateActivationWindow(activeFrom, activeUntil *time.Time, now time.Time) error {
+	if activeFrom != nil && activeUntil != nil && !activeUntil.After(*activeFrom) {
+		return fmt.Errorf("active_until must be after active_from: %w", ErrInvalidActivationWindow)
+	}
+
+	if activeUntil != nil && !activeUntil.After(now) {
+		return fmt.Errorf("active_until is in the past: %w", ErrInvalidActivationWindow)
+	}
+
+	return nil
+}
+
+func validRedirectCode(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
+
+func validQueryConflict(rule string) bool {
+	switch rule {
+	case entity.QueryConflictKeep, entity.QueryConflictOverride, entity.QueryConflictAppend:
+		return true
+	}
+
+	return false
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
+
+// getUserURL returns the url if it's owned by the user. Urls of other users are reported as not found.
+func (s *UrlService) getUserURL(ctx context.Context, alias string, userId int) (*entity.Url, error) {
+	url, err := s.urlRepository.GetURL(ctx, alias)
+	if err != nil {
+		if errors.Is(err, repository.ErrURLNotFound) {
+			return nil, fmt.Errorf("url not found: %w", ErrURLNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get url: %w", err)
+	}
+
+	if url.UserId != userId {
+		return nil, fmt.Errorf("url not found: %w", ErrURLNotFound)
+	}
+
+	return url, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+// urlsCursor is a serialized position in the list of urls. It remembers the order it was issued for,
+// so a cursor can't be used with a different sort order.
+type urlsCursor struct {
+	SortBy     repository.UrlsSortField `json:"s"`
+	Descending bool                     `json:"d"`
+	CreatedAt  time.Time                `json:"c,omitempty"`
+	Clicks     int                      `json:"k,omitempty"`
+	Id         int                      `json:"i"`
+}
+
+func encodeUrlsCursor(url entity.Url, params repository.ListURLsParams) string {
+	cursor := urlsCursor{
+		SortBy:     params.SortBy,
+		Descending: params.Descending,
+		Id:         url.Id,
+	}
+
+	if params.SortBy == repository.SortByClicks {
+		cursor.Clicks = url.ClickCount
+	} else {
+		cursor.CreatedAt = url.CreatedAt
+	}
+
+	// marshaling of the struct with simple fields can't fail
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUrlsCursor(s string, params repository.ListURLsParams) (*repository.UrlsCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", ErrInvalidListParams)
+	}
+
+	var cursor urlsCursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cursor: %w", ErrInvalidListParams)
+	}
+
+	if cursor.SortBy != params.SortBy || cursor.Descending != params.Descending {
+		return nil, fmt.Errorf("cursor was issued for another order: %w", ErrInvalidListParams)
+	}
+
+	return &repository.UrlsCursor{
+		CreatedAt: cursor.CreatedAt,
+		Clicks:    cursor.Clicks,
+		Id:        cursor.Id,
+	}, nil
+}
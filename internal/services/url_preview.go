@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+// PreviewURL returns the url to show on its preview page. Unlike GetURL it doesn't count a click,
+// but the url must still be available: protected urls must be unlocked to reveal their destination.
+func (s *UrlService) PreviewURL(ctx context.Context, input GetURLInput) (*entity.Url, error) {
+	url, err := s.availableURL(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if url.MaxClicks != nil && url.ClickCount >= *url.MaxClicks {
+		return nil, fmt.Errorf("url clicks limit exhausted: %w", ErrURLClicksExhausted)
+	}
+
+	return url, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+// MaxTargetingRules limits the number of targeting rules of one url, all of them are checked on every redirect.
+const MaxTargetingRules = 20
+
+type GetTargetingRulesInput struct {
+	Alias  string
+	UserId int
+}
+
+// GetTargetingRules returns the user's url with its targeting rules in the order they are checked.
+func (s *UrlService) GetTargetingRules(ctx context.Context, input GetTargetingRulesInput) (*entity.Url, error) {
+	url, err := s.getUserURL(ctx, input.Alias, input.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if url.TargetingRules, err = s.urlRepository.GetTargetingRules(ctx, url.Id); err != nil {
+		return nil, fmt.Errorf("failed to get url targeting rules: %w", err)
+	}
+
+	return url, nil
+}
+
+type SetTargetingRulesInput struct {
+	Alias  string
+	UserId int
+	// Rules are checked in the given order. Empty rules remove targeting, so the url always
+	// redirects to its own destination.
+	Rules []entity.TargetingRule
+}
+
+// SetTargetingRules replaces all targeting rules of the user's url.
+func (s *UrlService) SetTargetingRules(ctx context.Context, input SetTargetingRulesInput) error {
+	if len(input.Rules) > MaxTargetingRules {
+		return fmt.Errorf("url can't have more than %d targeting rules: %w", MaxTargetingRules, ErrTooManyTargetingRules)
+	}
+
+	for i := range input.Rules {
+		if input.Rules[i].IsEmpty() {
+			// a rule without conditions would shadow the url destination and all the rules after it
+			return fmt.Errorf("targeting rule %d has no conditions: %w", i, ErrEmptyTargetingRule)
+		}
+	}
+
+	url, err := s.getUserURL(ctx, input.Alias, input.UserId)
+	if err != nil {
+		return err
+	}
+
+	if err = s.urlRepository.ReplaceTargetingRules(ctx, url.Id, input.Rules); err != nil {
+		return fmt.Errorf("failed to replace url targeting rules: %w", err)
+	}
+
+	return nil
+}
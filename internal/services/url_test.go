@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/pkg/aliaspolicy"
+	"github.com/4aykovski/url_shortener/pkg/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUrlRepository struct {
+	urlRepository
+	url     *entity.Url
+	updated bool
+}
+
+func (r *fakeUrlRepository) GetURL(ctx context.Context, alias string) (*entity.Url, error) {
+	url := *r.url
+	return &url, nil
+}
+
+func (r *fakeUrlRepository) UpdateURL(ctx context.Context, url *entity.Url) error {
+	r.updated = true
+	return nil
+}
+
+func TestUrlServiceUpdateURLActivationWindow(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	newURL := "https://example.com/new"
+
+	tests := []struct {
+		name        string
+		activeUntil *time.Time
+		input       UpdateURLInput
+		wantErr     error
+	}{
+		{
+			name:    "active_until in the past",
+			input:   UpdateURLInput{ActiveUntil: &past},
+			wantErr: ErrInvalidActivationWindow,
+		},
+		{
+			name:    "active_until before active_from",
+			input:   UpdateURLInput{ActiveFrom: &future, ActiveUntil: &future},
+			wantErr: ErrInvalidActivationWindow,
+		},
+		{
+			name:  "active_until in the future",
+			input: UpdateURLInput{ActiveUntil: &future},
+		},
+		{
+			name:        "ended window isn't changed",
+			activeUntil: &past,
+			input:       UpdateURLInput{URL: &newURL},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &fakeUrlRepository{url: &entity.Url{Alias: "alias", UserId: 1, ActiveUntil: tc.activeUntil}}
+			s := NewUrlService(repo, nil, nil, nil, 0, plainHasher{}, nil, nil, "secret", time.Minute)
+
+			tc.input.Alias, tc.input.UserId = "alias", 1
+			err := s.UpdateURL(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				require.False(t, repo.updated)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, repo.updated)
+		})
+	}
+}
+
+func TestUrlServiceUnlockURLLimitsAliasFromAllIPs(t *testing.T) {
+	repo := &fakeUrlRepository{url: &entity.Url{Alias: "alias", PasswordHash: "secret"}}
+	s := NewUrlService(repo, nil, nil, nil, 0, plainHasher{},
+		ratelimit.NewFailureLimiter(2, time.Minute), ratelimit.NewFailureLimiter(3, time.Minute), "secret", time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := s.UnlockURL(context.Background(), UnlockURLInput{Alias: "alias", Password: "wrong", IP: fmt.Sprintf("10.0.0.%d", i)})
+		require.ErrorIs(t, err, ErrWrongURLPassword)
+	}
+
+	_, err := s.UnlockURL(context.Background(), UnlockURLInput{Alias: "alias", Password: "secret", IP: "10.0.0.3"})
+	require.ErrorIs(t, err, ErrTooManyUnlockAttempts)
+}
+
+type fakeAliasGenerator struct {
+	aliases []string
+}
+
+func (g *fakeAliasGenerator) Generate(ctx context.Context) (string, error) {
+	alias := g.aliases[0]
+	if len(g.aliases) > 1 {
+		g.aliases = g.aliases[1:]
+	}
+	return alias, nil
+}
+
+func TestUrlServiceGenerateAliasPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases []string
+		want    string
+		wantErr error
+	}{
+		{name: "allowed", aliases: []string{"abc123"}, want: "abc123"},
+		{name: "reserved is regenerated", aliases: []string{"api", "abc123"}, want: "abc123"},
+		{name: "too long is regenerated", aliases: []string{strings.Repeat("a", 33), "abc123"}, want: "abc123"},
+		{name: "never allowed", aliases: []string{"brave.otter"}, wantErr: aliaspolicy.ErrInvalidAlias},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewUrlService(nil, nil, &fakeAliasGenerator{aliases: tc.aliases}, aliaspolicy.Default(), 0, plainHasher{}, nil, nil, "secret", time.Minute)
+
+			alias, err := s.generateAlias(context.Background())
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, alias)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+)
+
+type UnlockURLInput struct {
+	Alias    string
+	Password string
+	// IP of the client, wrong attempts are limited per alias and ip.
+	IP string
+}
+
+type UnlockURLOutput struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// UnlockURL checks the password of the url and issues a token that allows to get the url until it expires.
+// Every attempt counts as failed until the password is right. Attempts are limited per url and ip and
+// per url from all ips, the latter bounds guesses of clients that change their ip.
+func (s *UrlService) UnlockURL(ctx context.Context, input UnlockURLInput) (UnlockURLOutput, error) {
+	key := input.Alias + "|" + input.IP
+	if !s.unlockLimiter.Attempt(key) || !s.aliasUnlockLimiter.Attempt(input.Alias) {
+		return UnlockURLOutput{}, fmt.Errorf("too many unlock attempts: %w", ErrTooManyUnlockAttempts)
+	}
+
+	url, err := s.urlRepository.GetURL(ctx, input.Alias)
+	if err != nil {
+		if errors.Is(err, repository.ErrURLNotFound) {
+			return UnlockURLOutput{}, fmt.Errorf("url not found: %w", ErrURLNotFound)
+		}
+
+		return UnlockURLOutput{}, fmt.Errorf("failed to get url: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	if url.IsExpired(now) {
+		return UnlockURLOutput{}, fmt.Errorf("url expired: %w", ErrURLExpired)
+	}
+
+	if url.IsProtected() && !s.hasher.CheckPassword(input.Password, url.PasswordHash) {
+		return UnlockURLOutput{}, fmt.Errorf("wrong url password: %w", ErrWrongURLPassword)
+	}
+
+	s.unlockLimiter.Reset(key)
+	s.aliasUnlockLimiter.Reset(input.Alias)
+
+	expiresAt := now.Add(s.unlockTTL)
+
+	return UnlockURLOutput{
+		Token:     s.signUnlockToken(url, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// signUnlockToken makes a token of expiration time and HMAC of it with the alias and the password hash,
+// so the token is valid only for one url and stops working once the password is changed.
+func (s *UrlService) signUnlockToken(url *entity.Url, expiresAt time.Time) string {
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	return exp + "." + base64.RawURLEncoding.EncodeToString(s.unlockMAC(url, exp))
+}
+
+func (s *UrlService) validUnlockToken(url *entity.Url, token string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !time.Unix(expUnix, 0).After(now) {
+		return false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(mac, s.unlockMAC(url, exp))
+}
+
+func (s *UrlService) unlockMAC(url *entity.Url, exp string) []byte {
+	mac := hmac.New(sha256.New, s.unlockSecret)
+	mac.Write([]byte(url.Alias + "|" + exp + "|" + url.PasswordHash))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/pkg/utm"
+)
+
+// MaxURLVariants limits the number of variants of one split-testing url.
+const MaxURLVariants = 10
+
+type UrlVariantInput struct {
+	URL string
+	// Weight is a relative share of visitors of the variant, e.g. 70 and 30 split visitors 70/30.
+	Weight int
+}
+
+type SetURLVariantsInput struct {
+	Alias  string
+	UserId int
+	// Variants replace all current variants, empty variants stop splitting the url.
+	Variants []UrlVariantInput
+}
+
+// SetURLVariants replaces split-testing variants of the user's url. Visitors assigned to removed variants
+// are assigned again on their next visit. Removed variants are archived with their clicks and the change
+// is recorded in the url history.
+func (s *UrlService) SetURLVariants(ctx context.Context, input SetURLVariantsInput) error {
+	if err := validateVariants(input.Variants); err != nil {
+		return err
+	}
+
+	url, err := s.getUserURL(ctx, input.Alias, input.UserId)
+	if err != nil {
+		return err
+	}
+
+	if url.Variants, err = newVariants(input.Variants, utm.Params{}); err != nil {
+		return err
+	}
+
+	if err = s.urlRepository.ReplaceURLVariants(ctx, url); err != nil {
+		return fmt.Errorf("failed to replace url variants: %w", err)
+	}
+
+	return nil
+}
+
+// validateVariants checks that variants really split visitors. Empty variants are valid and mean no split.
+func validateVariants(variants []UrlVariantInput) error {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	if len(variants) < 2 || len(variants) > MaxURLVariants {
+		return fmt.Errorf("url must have from 2 to %d variants: %w", MaxURLVariants, ErrInvalidURLVariants)
+	}
+
+	for i, variant := range variants {
+		if variant.Weight <= 0 {
+			return fmt.Errorf("weight of variant %d isn't positive: %w", i, ErrInvalidURLVariants)
+		}
+	}
+
+	return nil
+}
+
+func newVariants(variants []UrlVariantInput, params utm.Params) ([]entity.UrlVariant, error) {
+	res := make([]entity.UrlVariant, 0, len(variants))
+	for _, variant := range variants {
+		destination, err := utmDestination(variant.URL, params)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, entity.UrlVariant{
+			Url:    destination,
+			Weight: variant.Weight,
+		})
+	}
+
+	return res, nil
+}
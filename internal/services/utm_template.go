@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/4aykovski/url_shortener/internal/adapters/repository"
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/pkg/utm"
+)
+
+type utmTemplateRepository interface {
+	SaveUtmTemplate(ctx context.Context, template *entity.UtmTemplate) error
+	GetUtmTemplate(ctx context.Context, userId int, name string) (*entity.UtmTemplate, error)
+	GetUtmTemplates(ctx context.Context, userId int) ([]entity.UtmTemplate, error)
+	UpdateUtmTemplate(ctx context.Context, template *entity.UtmTemplate) error
+	DeleteUtmTemplate(ctx context.Context, userId int, name string) error
+}
+
+type UtmTemplateService struct {
+	utmTemplateRepository utmTemplateRepository
+}
+
+func NewUtmTemplateService(utmTemplateRepository utmTemplateRepository) *UtmTemplateService {
+	return &UtmTemplateService{
+		utmTemplateRepository: utmTemplateRepository,
+	}
+}
+
+type SaveUtmTemplateInput struct {
+	UserId int
+	Name   string
+	Params utm.Params
+}
+
+func (s *UtmTemplateService) CreateUtmTemplate(ctx context.Context, input SaveUtmTemplateInput) (*entity.UtmTemplate, error) {
+	if input.Params.IsEmpty() {
+		return nil, fmt.Errorf("template has no params: %w", ErrEmptyUtmTemplate)
+	}
+
+	template := newUtmTemplate(input)
+	if err := s.utmTemplateRepository.SaveUtmTemplate(ctx, template); err != nil {
+		if errors.Is(err, repository.ErrUtmTemplateExists) {
+			return nil, fmt.Errorf("utm template already exists: %w", ErrUtmTemplateExists)
+		}
+
+		return nil, fmt.Errorf("failed to save utm template: %w", err)
+	}
+
+	return template, nil
+}
+
+type GetUtmTemplatesInput struct {
+	UserId int
+}
+
+func (s *UtmTemplateService) GetUtmTemplates(ctx context.Context, input GetUtmTemplatesInput) ([]entity.UtmTemplate, error) {
+	templates, err := s.utmTemplateRepository.GetUtmTemplates(ctx, input.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get utm templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// UpdateUtmTemplate replaces all params of the template.
+func (s *UtmTemplateService) UpdateUtmTemplate(ctx context.Context, input SaveUtmTemplateInput) (*entity.UtmTemplate, error) {
+	if input.Params.IsEmpty() {
+		return nil, fmt.Errorf("template has no params: %w", ErrEmptyUtmTemplate)
+	}
+
+	template := newUtmTemplate(input)
+	if err := s.utmTemplateRepository.UpdateUtmTemplate(ctx, template); err != nil {
+		if errors.Is(err, repository.ErrUtmTemplateNotFound) {
+			return nil, fmt.Errorf("utm template not found: %w", ErrUtmTemplateNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to update utm template: %w", err)
+	}
+
+	return template, nil
+}
+
+type DeleteUtmTemplateInput struct {
+	UserId int
+	Name   string
+}
+
+func (s *UtmTemplateService) DeleteUtmTemplate(ctx context.Context, input DeleteUtmTemplateInput) error {
+	if err := s.utmTemplateRepository.DeleteUtmTemplate(ctx, input.UserId, input.Name); err != nil {
+		if errors.Is(err, repository.ErrUtmTemplateNotFound) {
+			return fmt.Errorf("utm template not found: %w", ErrUtmTemplateNotFound)
+		}
+
+		return fmt.Errorf("failed to delete utm template: %w", err)
+	}
+
+	return nil
+}
+
+func newUtmTemplate(input SaveUtmTemplateInput) *entity.UtmTemplate {
+	return &entity.UtmTemplate{
+		UserId:   input.UserId,
+		Name:     input.Name,
+		Source:   input.Params.Source,
+		Medium:   input.Params.Medium,
+		Campaign: input.Params.Campaign,
+		Term:     input.Params.Term,
+		Content:  input.Params.Content,
+	}
+}
+
+func utmTemplateParams(template *entity.UtmTemplate) utm.Params {
+	return utm.Params{
+		Source:   template.Source,
+		Medium:   template.Medium,
+		Campaign: template.Campaign,
+		Term:     template.Term,
+		Content:  template.Content,
+	}
+}
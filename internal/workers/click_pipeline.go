@@ -0,0 +1,230 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+)
+
+const (
+	clicksFlushTimeout   = 10 * time.Second
+	clicksReportInterval = time.Minute
+)
+
+// ErrInvalidClickPipelineOptions is returned by NewClickPipeline if the pipeline can't work with given options.
+var ErrInvalidClickPipelineOptions = errors.New("invalid click pipeline options")
+
+type clicksSaver interface {
+	SaveClicks(ctx context.Context, clicks []entity.Click) error
+}
+
+// ClickPipelineStats is a snapshot of the pipeline counters.
+type ClickPipelineStats struct {
+	Enqueued int64
+	Dropped  int64
+	Saved    int64
+	Failed   int64
+}
+
+// ClickPipeline buffers clicks in memory and saves them in batches by a pool of workers,
+// so redirects never wait for the database. When the buffer is full new clicks are dropped.
+type ClickPipeline struct {
+	log   *slog.Logger
+	saver clicksSaver
+
+	clicks        chan entity.Click
+	workers       int
+	batchSize     int
+	flushInterval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+	done   chan struct{}
+
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	saved    atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewClickPipeline creates the pipeline. Buffer size, workers, batch size and flush interval must be positive:
+// unbuffered pipeline drops almost every click and zero flush interval can't be used by the ticker.
+func NewClickPipeline(
+	log *slog.Logger,
+	saver clicksSaver,
+	bufferSize int,
+	workers int,
+	batchSize int,
+	flushInterval time.Duration,
+) (*ClickPipeline, error) {
+	const op = "workers.NewClickPipeline"
+
+	switch {
+	case bufferSize <= 0:
+		return nil, fmt.Errorf("%s: %w: buffer size must be positive", op, ErrInvalidClickPipelineOptions)
+	case workers <= 0:
+		return nil, fmt.Errorf("%s: %w: workers must be positive", op, ErrInvalidClickPipelineOptions)
+	case batchSize <= 0:
+		return nil, fmt.Errorf("%s: %w: batch size must be positive", op, ErrInvalidClickPipelineOptions)
+	case flushInterval <= 0:
+		return nil, fmt.Errorf("%s: %w: flush interval must be positive", op, ErrInvalidClickPipelineOptions)
+	}
+
+	return &ClickPipeline{
+		log:           log.With(slog.String("component", "workers/clickPipeline")),
+		saver:         saver,
+		clicks:        make(chan entity.Click, bufferSize),
+		workers:       workers,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}, nil
+}
+
+// Start launches workers. It must be called once before clicks are enqueued.
+func (p *ClickPipeline) Start() {
+	p.wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go p.work()
+	}
+
+	go p.report()
+}
+
+// Enqueue adds click to the buffer without blocking. It returns false if the click was dropped
+// because the buffer is full or the pipeline is shut down.
+func (p *ClickPipeline) Enqueue(click entity.Click) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case p.clicks <- click:
+		p.enqueued.Add(1)
+		return true
+	default:
+		p.dropped.Add(1)
+		return false
+	}
+}
+
+// Shutdown stops accepting new clicks and waits until buffered clicks are flushed or ctx is done.
+// If ctx is done first, the number of clicks that weren't saved yet is logged, they're lost
+// once the process exits.
+func (p *ClickPipeline) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.clicks)
+		close(p.done)
+	}
+	p.mu.Unlock()
+
+	flushed := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		p.log.Info("click pipeline stopped", slog.Any("stats", p.Stats()))
+		return nil
+	case <-ctx.Done():
+		stats := p.Stats()
+		p.log.Warn("click pipeline stopped before clicks are flushed, clicks dropped",
+			slog.Int64("dropped", stats.Enqueued-stats.Saved-stats.Failed),
+			slog.Any("stats", stats),
+		)
+		return ctx.Err()
+	}
+}
+
+func (p *ClickPipeline) Stats() ClickPipelineStats {
+	return ClickPipelineStats{
+		Enqueued: p.enqueued.Load(),
+		Dropped:  p.dropped.Load(),
+		Saved:    p.saved.Load(),
+		Failed:   p.failed.Load(),
+	}
+}
+
+func (p *ClickPipeline) work() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]entity.Click, 0, p.batchSize)
+	for {
+		select {
+		case click, ok := <-p.clicks:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+
+			batch = append(batch, click)
+			if len(batch) >= p.batchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			p.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (p *ClickPipeline) flush(batch []entity.Click) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clicksFlushTimeout)
+	defer cancel()
+
+	if err := p.saver.SaveClicks(ctx, batch); err != nil {
+		p.failed.Add(int64(len(batch)))
+		p.log.Error("failed to save clicks", slog.Int("count", len(batch)), slogHelper.Err(err))
+		return
+	}
+
+	p.saved.Add(int64(len(batch)))
+}
+
+// report periodically warns about dropped clicks, so buffer overflows don't stay unnoticed.
+func (p *ClickPipeline) report() {
+	ticker := time.NewTicker(clicksReportInterval)
+	defer ticker.Stop()
+
+	var lastDropped int64
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			stats := p.Stats()
+			if stats.Dropped > lastDropped {
+				p.log.Warn("click buffer overflowed, clicks dropped",
+					slog.Int64("dropped", stats.Dropped-lastDropped),
+					slog.Int("buffered", len(p.clicks)),
+					slog.Any("stats", stats),
+				)
+			}
+			lastDropped = stats.Dropped
+		}
+	}
+}
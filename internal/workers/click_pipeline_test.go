@@ -0,0 +1,143 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/4aykovski/url_shortener/internal/entity"
+	"github.com/4aykovski/url_shortener/pkg/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/require"
+)
+
+type clicksSaverStub struct {
+	mu      sync.Mutex
+	batches [][]entity.Click
+	block   chan struct{}
+}
+
+func (s *clicksSaverStub) SaveClicks(_ context.Context, clicks []entity.Click) error {
+	if s.block != nil {
+		<-s.block
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch := make([]entity.Click, len(clicks))
+	copy(batch, clicks)
+	s.batches = append(s.batches, batch)
+
+	return nil
+}
+
+func (s *clicksSaverStub) saved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for _, batch := range s.batches {
+		n += len(batch)
+	}
+	return n
+}
+
+func TestClickPipelineFlushesOnShutdown(t *testing.T) {
+	saver := &clicksSaverStub{}
+	p, err := NewClickPipeline(slogdiscard.NewDiscardLogger(), saver, 100, 2, 10, time.Hour)
+	require.NoError(t, err)
+	p.Start()
+
+	for i := 0; i < 25; i++ {
+		require.True(t, p.Enqueue(entity.Click{UrlId: i}))
+	}
+
+	require.NoError(t, p.Shutdown(context.Background()))
+	require.Equal(t, 25, saver.saved())
+
+	for _, batch := range saver.batches {
+		require.LessOrEqual(t, len(batch), 10)
+	}
+
+	require.False(t, p.Enqueue(entity.Click{}))
+	require.Equal(t, ClickPipelineStats{Enqueued: 25, Dropped: 1, Saved: 25}, p.Stats())
+}
+
+func TestClickPipelineFlushesOnInterval(t *testing.T) {
+	saver := &clicksSaverStub{}
+	p, err := NewClickPipeline(slogdiscard.NewDiscardLogger(), saver, 100, 1, 10, 10*time.Millisecond)
+	require.NoError(t, err)
+	p.Start()
+	defer func() { _ = p.Shutdown(context.Background()) }()
+
+	require.True(t, p.Enqueue(entity.Click{UrlId: 1}))
+
+	require.Eventually(t, func() bool { return saver.saved() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestClickPipelineDropsWhenBufferIsFull(t *testing.T) {
+	saver := &clicksSaverStub{block: make(chan struct{})}
+	p, err := NewClickPipeline(slogdiscard.NewDiscardLogger(), saver, 2, 1, 1, time.Hour)
+	require.NoError(t, err)
+	p.Start()
+
+	// the worker takes the first click and blocks on saving it, so the buffer holds two more
+	require.True(t, p.Enqueue(entity.Click{UrlId: 1}))
+	require.Eventually(t, func() bool { return len(p.clicks) == 0 }, time.Second, time.Millisecond)
+	require.True(t, p.Enqueue(entity.Click{UrlId: 2}))
+	require.True(t, p.Enqueue(entity.Click{UrlId: 3}))
+	require.False(t, p.Enqueue(entity.Click{UrlId: 4}))
+
+	close(saver.block)
+	require.NoError(t, p.Shutdown(context.Background()))
+
+	require.Equal(t, ClickPipelineStats{Enqueued: 3, Dropped: 1, Saved: 3}, p.Stats())
+}
+
+func TestClickPipelineShutdownTimeout(t *testing.T) {
+	saver := &clicksSaverStub{block: make(chan struct{})}
+	p, err := NewClickPipeline(slogdiscard.NewDiscardLogger(), saver, 10, 1, 1, time.Hour)
+	require.NoError(t, err)
+	p.Start()
+
+	require.True(t, p.Enqueue(entity.Click{UrlId: 1}))
+	require.True(t, p.Enqueue(entity.Click{UrlId: 2}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+	require.Equal(t, 0, saver.saved())
+
+	close(saver.block)
+	require.NoError(t, p.Shutdown(context.Background()))
+	require.Equal(t, 2, saver.saved())
+}
+
+func TestNewClickPipelineInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		bufferSize    int
+		workers       int
+		batchSize     int
+		flushInterval time.Duration
+	}{
+		{name: "zero buffer size", bufferSize: 0, workers: 1, batchSize: 1, flushInterval: time.Second},
+		{name: "zero workers", bufferSize: 1, workers: 0, batchSize: 1, flushInterval: time.Second},
+		{name: "negative batch size", bufferSize: 1, workers: 1, batchSize: -1, flushInterval: time.Second},
+		{name: "zero flush interval", bufferSize: 1, workers: 1, batchSize: 1, flushInterval: 0},
+		{name: "negative flush interval", bufferSize: 1, workers: 1, batchSize: 1, flushInterval: -time.Second},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewClickPipeline(slogdiscard.NewDiscardLogger(), &clicksSaverStub{}, tc.bufferSize, tc.workers, tc.batchSize, tc.flushInterval)
+			require.ErrorIs(t, err, ErrInvalidClickPipelineOptions)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+)
+
+type reloader interface {
+	Reload() (bool, error)
+}
+
+// GeoIPReloader periodically checks the GeoIP database file and reloads it when it's replaced,
+// so the database can be updated without restarting the app.
+type GeoIPReloader struct {
+	log      *slog.Logger
+	db       reloader
+	interval time.Duration
+}
+
+func NewGeoIPReloader(log *slog.Logger, db reloader, interval time.Duration) *GeoIPReloader {
+	return &GeoIPReloader{
+		log:      log.With(slog.String("component", "workers/geoIPReloader")),
+		db:       db,
+		interval: interval,
+	}
+}
+
+// Run checks the database file every interval until ctx is done.
+func (r *GeoIPReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := r.db.Reload()
+		if err != nil {
+			r.log.Error("failed to reload geoip database", slogHelper.Err(err))
+			continue
+		}
+		if reloaded {
+			r.log.Info("geoip database reloaded")
+		}
+	}
+}
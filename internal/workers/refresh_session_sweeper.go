@@ -0,0 +1,62 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+)
+
+type expiredRefreshSessionsPurger interface {
+	PurgeExpiredRefreshSessions(ctx context.Context) (int64, error)
+}
+
+// RefreshSessionSweeper periodically removes session families which newest session has expired,
+// so the refresh_sessions table doesn't grow with sessions of clients that never refresh again.
+type RefreshSessionSweeper struct {
+	log    *slog.Logger
+	purger expiredRefreshSessionsPurger
+
+	interval time.Duration
+}
+
+func NewRefreshSessionSweeper(
+	log *slog.Logger,
+	purger expiredRefreshSessionsPurger,
+	interval time.Duration,
+) *RefreshSessionSweeper {
+	return &RefreshSessionSweeper{
+		log:      log.With(slog.String("component", "workers/refreshSessionSweeper")),
+		purger:   purger,
+		interval: interval,
+	}
+}
+
+// Run sweeps expired refresh sessions every interval until ctx is done.
+func (s *RefreshSessionSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *RefreshSessionSweeper) sweep(ctx context.Context) {
+	deleted, err := s.purger.PurgeExpiredRefreshSessions(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("failed to purge expired refresh sessions", slogHelper.Err(err))
+		}
+		return
+	}
+
+	s.log.Debug("expired refresh sessions purged", slog.Int64("deleted", deleted))
+}
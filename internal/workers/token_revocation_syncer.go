@@ -0,0 +1,47 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+)
+
+type revocationSyncer interface {
+	Sync(ctx context.Context) error
+}
+
+// TokenRevocationSyncer periodically loads revoked access tokens from the database, so tokens revoked
+// by other instances are rejected here too, and drops the expired revocations.
+type TokenRevocationSyncer struct {
+	log         *slog.Logger
+	revocations revocationSyncer
+	interval    time.Duration
+}
+
+func NewTokenRevocationSyncer(log *slog.Logger, revocations revocationSyncer, interval time.Duration) *TokenRevocationSyncer {
+	return &TokenRevocationSyncer{
+		log:         log.With(slog.String("component", "workers/tokenRevocationSyncer")),
+		revocations: revocations,
+		interval:    interval,
+	}
+}
+
+// Run syncs revocations every interval until ctx is done.
+func (s *TokenRevocationSyncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.revocations.Sync(ctx); err != nil {
+			s.log.Error("failed to sync token revocations", slogHelper.Err(err))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/4aykovski/url_shortener/pkg/logger/slogHelper"
+)
+
+type expiredURLsPurger interface {
+	PurgeExpiredURLs(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// URLSweeper periodically removes urls that have been expired for longer than retention,
+// so the urls table doesn't grow unbounded.
+type URLSweeper struct {
+	log    *slog.Logger
+	purger expiredURLsPurger
+
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewURLSweeper(
+	log *slog.Logger,
+	purger expiredURLsPurger,
+	interval time.Duration,
+	retention time.Duration,
+) *URLSweeper {
+	return &URLSweeper{
+		log:       log.With(slog.String("component", "workers/urlSweeper")),
+		purger:    purger,
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+// Run sweeps expired urls every interval until ctx is done.
+func (s *URLSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *URLSweeper) sweep(ctx context.Context) {
+	deleted, err := s.purger.PurgeExpiredURLs(ctx, s.retention)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("failed to purge expired urls", slogHelper.Err(err))
+		}
+		return
+	}
+
+	s.log.Debug("expired urls purged", slog.Int64("deleted", deleted))
+}
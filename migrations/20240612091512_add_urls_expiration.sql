@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS urls_expires_at_idx ON urls(expires_at) WHERE expires_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS urls_expires_at_idx;
+
+ALTER TABLE urls DROP COLUMN expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls ADD COLUMN max_clicks INT CHECK (max_clicks > 0);
+ALTER TABLE urls ADD COLUMN click_count INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls DROP COLUMN click_count;
+ALTER TABLE urls DROP COLUMN max_clicks;
+-- +goose StatementEnd
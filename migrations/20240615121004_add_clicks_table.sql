@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS clicks
+(
+  id BIGSERIAL PRIMARY KEY,
+  url_id INT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+  clicked_at TIMESTAMP NOT NULL,
+  referrer TEXT NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  visitor_hash TEXT NOT NULL,
+  request_id TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS clicks_url_id_clicked_at_idx ON clicks(url_id, clicked_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS clicks;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS url_versions
+(
+  id SERIAL PRIMARY KEY,
+  url_id INT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+  version INT NOT NULL,
+  old_url TEXT,
+  new_url TEXT NOT NULL,
+  changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+  changed_at TIMESTAMP NOT NULL,
+  UNIQUE (url_id, version)
+);
+
+INSERT INTO url_versions(url_id, version, old_url, new_url, changed_by, changed_at)
+SELECT id, 1, NULL, url, user_id, now() AT TIME ZONE 'UTC' FROM urls;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS url_versions;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC');
+
+CREATE INDEX IF NOT EXISTS urls_user_id_created_at_idx ON urls(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS urls_user_id_click_count_idx ON urls(user_id, click_count, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS urls_user_id_click_count_idx;
+DROP INDEX IF EXISTS urls_user_id_created_at_idx;
+
+ALTER TABLE urls DROP COLUMN created_at;
+-- +goose StatementEnd
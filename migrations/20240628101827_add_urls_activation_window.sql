@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls ADD COLUMN active_from TIMESTAMP;
+ALTER TABLE urls ADD COLUMN active_until TIMESTAMP;
+ALTER TABLE urls ADD COLUMN fallback_url TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls DROP COLUMN fallback_url;
+ALTER TABLE urls DROP COLUMN active_until;
+ALTER TABLE urls DROP COLUMN active_from;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls ADD COLUMN redirect_code SMALLINT NOT NULL DEFAULT 302
+    CONSTRAINT urls_redirect_code_check CHECK (redirect_code IN (301, 302, 307, 308));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls DROP COLUMN redirect_code;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls ADD COLUMN forward_query BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE urls ADD COLUMN query_conflict TEXT NOT NULL DEFAULT 'keep'
+    CONSTRAINT urls_query_conflict_check CHECK (query_conflict IN ('keep', 'override', 'append'));
+ALTER TABLE urls ADD COLUMN prefix_mode BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls DROP COLUMN prefix_mode;
+ALTER TABLE urls DROP COLUMN query_conflict;
+ALTER TABLE urls DROP COLUMN forward_query;
+-- +goose StatementEnd
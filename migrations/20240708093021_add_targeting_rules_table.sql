@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS targeting_rules
+(
+  id SERIAL PRIMARY KEY,
+  url_id INT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  os TEXT NOT NULL DEFAULT '',
+  device TEXT NOT NULL DEFAULT '',
+  browser TEXT NOT NULL DEFAULT '',
+  bot BOOLEAN,
+  url TEXT NOT NULL,
+  UNIQUE (url_id, position)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS targeting_rules;
+-- +goose StatementEnd
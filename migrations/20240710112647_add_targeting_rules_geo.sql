@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE targeting_rules ADD COLUMN country TEXT NOT NULL DEFAULT '';
+ALTER TABLE targeting_rules ADD COLUMN region TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE targeting_rules DROP COLUMN region;
+ALTER TABLE targeting_rules DROP COLUMN country;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS url_variants
+(
+  id SERIAL PRIMARY KEY,
+  url_id INT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  url TEXT NOT NULL,
+  weight INT NOT NULL CONSTRAINT url_variants_weight_check CHECK (weight > 0),
+  UNIQUE (url_id, position)
+);
+
+ALTER TABLE clicks ADD COLUMN variant_id INT REFERENCES url_variants(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS clicks_url_id_variant_id_idx ON clicks(url_id, variant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS clicks_url_id_variant_id_idx;
+
+ALTER TABLE clicks DROP COLUMN variant_id;
+
+DROP TABLE IF EXISTS url_variants;
+-- +goose StatementEnd
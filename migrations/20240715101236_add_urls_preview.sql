@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls ADD COLUMN title TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN interstitial BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls DROP COLUMN interstitial;
+ALTER TABLE urls DROP COLUMN description;
+ALTER TABLE urls DROP COLUMN title;
+-- +goose StatementEnd
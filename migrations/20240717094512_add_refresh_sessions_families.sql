@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_sessions
+  ADD COLUMN family_id TEXT,
+  ADD COLUMN parent_id INT REFERENCES refresh_sessions(id) ON DELETE SET NULL,
+  ADD COLUMN rotated_at TIMESTAMP;
+
+UPDATE refresh_sessions SET family_id = id::TEXT;
+
+ALTER TABLE refresh_sessions ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS refresh_sessions_family_id_idx ON refresh_sessions(family_id);
+CREATE INDEX IF NOT EXISTS refresh_sessions_refresh_token_idx ON refresh_sessions(refresh_token);
+
+CREATE TABLE IF NOT EXISTS security_events
+(
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  type TEXT NOT NULL,
+  details TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS security_events_user_id_idx ON security_events(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS security_events;
+
+DROP INDEX IF EXISTS refresh_sessions_refresh_token_idx;
+DROP INDEX IF EXISTS refresh_sessions_family_id_idx;
+
+ALTER TABLE refresh_sessions
+  DROP COLUMN rotated_at,
+  DROP COLUMN parent_id,
+  DROP COLUMN family_id;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Plaintext tokens can't be converted to digests without trusting them, so every session is revoked
+-- and users have to sign in again.
+DELETE FROM refresh_sessions;
+
+DROP INDEX IF EXISTS refresh_sessions_refresh_token_idx;
+
+ALTER TABLE refresh_sessions RENAME COLUMN refresh_token TO refresh_token_hash;
+
+CREATE UNIQUE INDEX IF NOT EXISTS refresh_sessions_refresh_token_hash_idx ON refresh_sessions(refresh_token_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Digests can't be turned back into tokens, so sessions are revoked again.
+DELETE FROM refresh_sessions;
+
+DROP INDEX IF EXISTS refresh_sessions_refresh_token_hash_idx;
+
+ALTER TABLE refresh_sessions RENAME COLUMN refresh_token_hash TO refresh_token;
+
+CREATE INDEX IF NOT EXISTS refresh_sessions_refresh_token_idx ON refresh_sessions(refresh_token);
+-- +goose StatementEnd
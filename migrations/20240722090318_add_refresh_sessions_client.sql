@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_sessions
+  ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+  ADD COLUMN ip TEXT NOT NULL DEFAULT '',
+  ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+  ADD COLUMN last_used_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC');
+
+CREATE INDEX IF NOT EXISTS refresh_sessions_user_id_idx ON refresh_sessions(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS refresh_sessions_user_id_idx;
+
+ALTER TABLE refresh_sessions
+  DROP COLUMN last_used_at,
+  DROP COLUMN created_at,
+  DROP COLUMN ip,
+  DROP COLUMN user_agent;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+  jti TEXT PRIMARY KEY,
+  user_id INT NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens(expires_at);
+
+-- no foreign key to users: revocations must outlive deleted accounts until their tokens expire
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+  user_id INT PRIMARY KEY,
+  revoked_before TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE users ADD COLUMN banned_at TIMESTAMP NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN banned_at;
+
+DROP TABLE IF EXISTS user_token_revocations;
+
+DROP INDEX IF EXISTS revoked_tokens_expires_at_idx;
+
+DROP TABLE IF EXISTS revoked_tokens;
+-- +goose StatementEnd
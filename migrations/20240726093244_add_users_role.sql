@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- admins are appointed manually: UPDATE users SET role = 'admin' WHERE login = '...';
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the active session is the newest one of its family, expired families are found by it
+CREATE INDEX IF NOT EXISTS refresh_sessions_active_expires_in_idx ON refresh_sessions(expires_in) WHERE rotated_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS refresh_sessions_active_expires_in_idx;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE url_variants ADD COLUMN archived_at TIMESTAMP;
+
+ALTER TABLE url_variants DROP CONSTRAINT IF EXISTS url_variants_url_id_position_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS url_variants_url_id_position_idx ON url_variants(url_id, position) WHERE archived_at IS NULL;
+
+ALTER TABLE url_versions ADD COLUMN variants JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE url_versions DROP COLUMN variants;
+
+DROP INDEX IF EXISTS url_variants_url_id_position_idx;
+
+DELETE FROM url_variants WHERE archived_at IS NOT NULL;
+
+ALTER TABLE url_variants ADD CONSTRAINT url_variants_url_id_position_key UNIQUE (url_id, position);
+
+ALTER TABLE url_variants DROP COLUMN archived_at;
+-- +goose StatementEnd
@@ -0,0 +1,136 @@
+package aliasgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	StrategyRandom   = "random"
+	StrategySequence = "sequence"
+	StrategyHashids  = "hashids"
+	StrategyWords    = "words"
+
+	DefaultAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+var (
+	ErrUnknownStrategy = errors.New("unknown alias strategy")
+	ErrInvalidOptions  = errors.New("invalid alias generator options")
+)
+
+// Generator generates aliases for new urls. Generated aliases can collide with existing ones,
+// so the caller is expected to retry on collision.
+type Generator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// Sequence is a source of unique increasing ids, e.g. a database sequence.
+type Sequence interface {
+	NextID(ctx context.Context) (int64, error)
+}
+
+type Options struct {
+	// Length is a minimal length of aliases. Random aliases have exactly this length.
+	Length int
+	// Alphabet is a set of characters aliases consist of. It isn't used by words strategy.
+	Alphabet string
+	// Salt makes hashids aliases unpredictable.
+	Salt string
+	// Words is a number of words in aliases of words strategy.
+	Words int
+}
+
+// New creates generator of the strategy. Sequence is required by sequence and hashids strategies.
+func New(strategy string, opts Options, seq Sequence) (Generator, error) {
+	const op = "lib.aliasgen.New"
+
+	if opts.Alphabet == "" {
+		opts.Alphabet = DefaultAlphabet
+	}
+
+	if err := validateOptions(strategy, opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch strategy {
+	case StrategyRandom:
+		return NewRandomGenerator(opts.Length, opts.Alphabet), nil
+	case StrategySequence:
+		return NewSequenceGenerator(seq, opts.Length, opts.Alphabet), nil
+	case StrategyHashids:
+		return NewHashidsGenerator(seq, opts.Length, opts.Alphabet, opts.Salt), nil
+	case StrategyWords:
+		return NewWordsGenerator(opts.Words), nil
+	}
+
+	return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownStrategy, strategy)
+}
+
+func validateOptions(strategy string, opts Options) error {
+	if strategy == StrategyWords {
+		if opts.Words < 1 {
+			return fmt.Errorf("%w: words count must be positive", ErrInvalidOptions)
+		}
+		return nil
+	}
+
+	if opts.Length < 1 {
+		return fmt.Errorf("%w: length must be positive", ErrInvalidOptions)
+	}
+
+	seen := make(map[rune]bool)
+	for _, r := range opts.Alphabet {
+		if seen[r] {
+			return fmt.Errorf("%w: alphabet contains duplicate character %q", ErrInvalidOptions, r)
+		}
+		seen[r] = true
+	}
+
+	if len(seen) < 2 {
+		return fmt.Errorf("%w: alphabet must contain at least 2 characters", ErrInvalidOptions)
+	}
+
+	return nil
+}
+
+// encode converts n to the positional numeral system with alphabet digits.
+func encode(n uint64, alphabet []rune) string {
+	base := uint64(len(alphabet))
+
+	var buf []rune
+	for {
+		buf = append(buf, alphabet[n%base])
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+
+	return string(buf)
+}
+
+// minValue returns the smallest number that is encoded with at least length digits.
+// Adding it to ids pads aliases to the length while keeping them unique.
+func minValue(length int, base int) uint64 {
+	if length <= 1 {
+		return 0
+	}
+
+	n := uint64(1)
+	for i := 1; i < length; i++ {
+		next := n * uint64(base)
+		if next/uint64(base) != n {
+			// overflow, aliases can't be that long anyway
+			return n
+		}
+		n = next
+	}
+
+	return n
+}
@@ -0,0 +1,159 @@
+package aliasgen
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSequence struct {
+	id  int64
+	err error
+}
+
+func (s *fakeSequence) NextID(_ context.Context) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.id++
+	return s.id, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		opts     Options
+		wantErr  error
+	}{
+		{
+			name:     "random",
+			strategy: StrategyRandom,
+			opts:     Options{Length: 6},
+		},
+		{
+			name:     "sequence",
+			strategy: StrategySequence,
+			opts:     Options{Length: 6},
+		},
+		{
+			name:     "hashids",
+			strategy: StrategyHashids,
+			opts:     Options{Length: 6, Salt: "salt"},
+		},
+		{
+			name:     "words",
+			strategy: StrategyWords,
+			opts:     Options{Words: 3},
+		},
+		{
+			name:     "unknown strategy",
+			strategy: "uuid",
+			opts:     Options{Length: 6},
+			wantErr:  ErrUnknownStrategy,
+		},
+		{
+			name:     "zero length",
+			strategy: StrategyRandom,
+			opts:     Options{Length: 0},
+			wantErr:  ErrInvalidOptions,
+		},
+		{
+			name:     "duplicate characters in alphabet",
+			strategy: StrategySequence,
+			opts:     Options{Length: 6, Alphabet: "abca"},
+			wantErr:  ErrInvalidOptions,
+		},
+		{
+			name:     "single character alphabet",
+			strategy: StrategyHashids,
+			opts:     Options{Length: 6, Alphabet: "a"},
+			wantErr:  ErrInvalidOptions,
+		},
+		{
+			name:     "zero words",
+			strategy: StrategyWords,
+			opts:     Options{Words: 0},
+			wantErr:  ErrInvalidOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := New(tt.strategy, tt.opts, &fakeSequence{})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			alias, err := gen.Generate(context.Background())
+			require.NoError(t, err)
+			assert.NotEmpty(t, alias)
+		})
+	}
+}
+
+func TestRandomGenerator(t *testing.T) {
+	gen := NewRandomGenerator(8, "abc")
+
+	for i := 0; i < 100; i++ {
+		alias, err := gen.Generate(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, alias, 8)
+		assert.Empty(t, strings.Trim(alias, "abc"))
+	}
+}
+
+func TestSequenceGenerator(t *testing.T) {
+	gen := NewSequenceGenerator(&fakeSequence{}, 4, DefaultAlphabet)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		alias, err := gen.Generate(context.Background())
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(alias), 4)
+		require.False(t, seen[alias], "duplicate alias %s", alias)
+		seen[alias] = true
+	}
+
+	_, err := NewSequenceGenerator(&fakeSequence{err: errors.New("db is down")}, 4, DefaultAlphabet).
+		Generate(context.Background())
+	require.Error(t, err)
+}
+
+func TestHashidsGenerator(t *testing.T) {
+	gen := NewHashidsGenerator(&fakeSequence{}, 6, DefaultAlphabet, "salt")
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		alias, err := gen.Generate(context.Background())
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(alias), 6)
+		require.False(t, seen[alias], "duplicate alias %s", alias)
+		seen[alias] = true
+	}
+
+	// the same id with another salt gives another alias
+	a := NewHashidsGenerator(nil, 6, DefaultAlphabet, "salt").encode(42)
+	b := NewHashidsGenerator(nil, 6, DefaultAlphabet, "pepper").encode(42)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, a, NewHashidsGenerator(nil, 6, DefaultAlphabet, "salt").encode(42))
+}
+
+func TestWordsGenerator(t *testing.T) {
+	gen := NewWordsGenerator(3)
+
+	alias, err := gen.Generate(context.Background())
+	require.NoError(t, err)
+
+	parts := strings.Split(alias, wordsSeparator)
+	require.Len(t, parts, 3)
+	for _, p := range parts {
+		assert.Contains(t, words, p)
+	}
+}
@@ -0,0 +1,73 @@
+package aliasgen
+
+import (
+	"context"
+	"fmt"
+)
+
+// HashidsGenerator encodes ids of the sequence like Hashids does: the alphabet is shuffled with the salt
+// and the id itself, so consecutive ids give unrelated aliases. Aliases are unique as long as
+// the alphabet and the salt don't change.
+type HashidsGenerator struct {
+	seq      Sequence
+	alphabet []rune
+	salt     []rune
+	offset   uint64
+}
+
+func NewHashidsGenerator(seq Sequence, length int, alphabet string, salt string) *HashidsGenerator {
+	saltRunes := []rune(salt)
+	runes := shuffle([]rune(alphabet), saltRunes)
+
+	// one character of the alias is taken by the lottery
+	coreLength := length - 1
+	if coreLength < 1 {
+		coreLength = 1
+	}
+
+	return &HashidsGenerator{
+		seq:      seq,
+		alphabet: runes,
+		salt:     saltRunes,
+		offset:   minValue(coreLength, len(runes)),
+	}
+}
+
+func (g *HashidsGenerator) Generate(ctx context.Context) (string, error) {
+	const op = "lib.aliasgen.HashidsGenerator.Generate"
+
+	id, err := g.seq.NextID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return g.encode(uint64(id)), nil
+}
+
+// encode prefixes the alias with a lottery character picked by id and encodes id with the alphabet
+// shuffled by the lottery and the salt. Ids with the same lottery share the alphabet, so aliases are unique.
+func (g *HashidsGenerator) encode(id uint64) string {
+	lottery := g.alphabet[id%uint64(len(g.alphabet))]
+
+	key := append([]rune{lottery}, g.salt...)
+	alphabet := shuffle(append([]rune(nil), g.alphabet...), key)
+
+	return string(lottery) + encode(id+g.offset, alphabet)
+}
+
+// shuffle is a consistent shuffle of the alphabet by the key used by Hashids.
+func shuffle(alphabet []rune, key []rune) []rune {
+	if len(key) == 0 {
+		return alphabet
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(key)
+		n := int(key[v])
+		p += n
+		j := (n + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+
+	return alphabet
+}
@@ -0,0 +1,38 @@
+package aliasgen
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// RandomGenerator generates cryptographically random aliases of fixed length.
+type RandomGenerator struct {
+	length   int
+	alphabet []rune
+}
+
+func NewRandomGenerator(length int, alphabet string) *RandomGenerator {
+	return &RandomGenerator{
+		length:   length,
+		alphabet: []rune(alphabet),
+	}
+}
+
+func (g *RandomGenerator) Generate(_ context.Context) (string, error) {
+	const op = "lib.aliasgen.RandomGenerator.Generate"
+
+	max := big.NewInt(int64(len(g.alphabet)))
+
+	b := make([]rune, g.length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		b[i] = g.alphabet[n.Int64()]
+	}
+
+	return string(b), nil
+}
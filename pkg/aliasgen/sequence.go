@@ -0,0 +1,35 @@
+package aliasgen
+
+import (
+	"context"
+	"fmt"
+)
+
+// SequenceGenerator encodes ids of the sequence with the alphabet, e.g. base62.
+// Aliases are unique but predictable.
+type SequenceGenerator struct {
+	seq      Sequence
+	alphabet []rune
+	offset   uint64
+}
+
+func NewSequenceGenerator(seq Sequence, length int, alphabet string) *SequenceGenerator {
+	runes := []rune(alphabet)
+
+	return &SequenceGenerator{
+		seq:      seq,
+		alphabet: runes,
+		offset:   minValue(length, len(runes)),
+	}
+}
+
+func (g *SequenceGenerator) Generate(ctx context.Context) (string, error) {
+	const op = "lib.aliasgen.SequenceGenerator.Generate"
+
+	id, err := g.seq.NextID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return encode(uint64(id)+g.offset, g.alphabet), nil
+}
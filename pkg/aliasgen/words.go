@@ -0,0 +1,43 @@
+package aliasgen
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const wordsSeparator = "-"
+
+//go:embed words.txt
+var wordsFile string
+
+var words = strings.Fields(wordsFile)
+
+// WordsGenerator generates human-readable aliases of random words from the embedded list, e.g. brave-otter-lake.
+type WordsGenerator struct {
+	count int
+}
+
+func NewWordsGenerator(count int) *WordsGenerator {
+	return &WordsGenerator{count: count}
+}
+
+func (g *WordsGenerator) Generate(_ context.Context) (string, error) {
+	const op = "lib.aliasgen.WordsGenerator.Generate"
+
+	max := big.NewInt(int64(len(words)))
+
+	parts := make([]string, g.count)
+	for i := range parts {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		parts[i] = words[n.Int64()]
+	}
+
+	return strings.Join(parts, wordsSeparator), nil
+}
@@ -0,0 +1,175 @@
+package aliaspolicy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const DefaultCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// DefaultReserved are words that clash with current or possible future routes.
+var DefaultReserved = []string{
+	"api", "admin", "login", "logout", "signin", "signup", "auth", "users", "urls",
+	"batch", "import", "export", "stats", "history", "rollback", "health", "metrics", "static",
+}
+
+var (
+	ErrInvalidOptions = errors.New("invalid alias policy options")
+	ErrInvalidAlias   = errors.New("alias doesn't match alias policy")
+)
+
+type Options struct {
+	MinLength int
+	MaxLength int
+	// Charset is a set of characters allowed in aliases.
+	Charset string
+	// Reserved words can't be used as aliases, the match is case-insensitive.
+	Reserved []string
+	// Blocked words can't be a part of aliases, e.g. profanity.
+	Blocked []string
+}
+
+// Policy decides which custom aliases users are allowed to choose.
+type Policy struct {
+	minLength int
+	maxLength int
+	charset   map[rune]struct{}
+	reserved  map[string]struct{}
+	blocked   []string
+}
+
+func New(opts Options) (*Policy, error) {
+	const op = "lib.aliaspolicy.New"
+
+	if opts.Charset == "" {
+		opts.Charset = DefaultCharset
+	}
+
+	if opts.MinLength < 1 || opts.MaxLength < opts.MinLength {
+		return nil, fmt.Errorf("%s: %w: length bounds must be positive and min must not exceed max", op, ErrInvalidOptions)
+	}
+
+	p := &Policy{
+		minLength: opts.MinLength,
+		maxLength: opts.MaxLength,
+		charset:   make(map[rune]struct{}),
+		reserved:  make(map[string]struct{}),
+	}
+
+	for _, r := range opts.Charset {
+		p.charset[r] = struct{}{}
+	}
+
+	for _, word := range opts.Reserved {
+		p.reserved[strings.ToLower(word)] = struct{}{}
+	}
+
+	for _, word := range opts.Blocked {
+		if word = normalize(word); word != "" {
+			p.blocked = append(p.blocked, word)
+		}
+	}
+
+	return p, nil
+}
+
+// Default returns the policy with base62 charset, dashes and underscores, length from 3 to 32 and default reserved words.
+func Default() *Policy {
+	p, _ := New(Options{
+		MinLength: 3,
+		MaxLength: 32,
+		Reserved:  DefaultReserved,
+	})
+	return p
+}
+
+func (p *Policy) MinLength() int {
+	return p.minLength
+}
+
+func (p *Policy) MaxLength() int {
+	return p.maxLength
+}
+
+func (p *Policy) ValidLength(alias string) bool {
+	n := utf8.RuneCountInString(alias)
+	return n >= p.minLength && n <= p.maxLength
+}
+
+// ValidCharset reports whether the alias consists of allowed characters only.
+func (p *Policy) ValidCharset(alias string) bool {
+	for _, r := range alias {
+		if _, ok := p.charset[r]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// IsReserved reports whether the alias is a reserved word or contains a blocked one.
+// Blocked words are searched ignoring case, dashes and underscores, so "Bad-Word" is caught too.
+func (p *Policy) IsReserved(alias string) bool {
+	if _, ok := p.reserved[strings.ToLower(alias)]; ok {
+		return true
+	}
+
+	normalized := normalize(alias)
+	for _, word := range p.blocked {
+		if strings.Contains(normalized, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the alias against all rules of the policy.
+func (p *Policy) Validate(alias string) error {
+	switch {
+	case !p.ValidLength(alias):
+		return fmt.Errorf("%w: length must be between %d and %d", ErrInvalidAlias, p.minLength, p.maxLength)
+	case !p.ValidCharset(alias):
+		return fmt.Errorf("%w: alias contains not allowed characters", ErrInvalidAlias)
+	case p.IsReserved(alias):
+		return fmt.Errorf("%w: alias is reserved", ErrInvalidAlias)
+	}
+
+	return nil
+}
+
+// LoadWords reads a word list file with one word per line. Empty lines and lines starting with # are skipped.
+func LoadWords(path string) ([]string, error) {
+	const op = "lib.aliaspolicy.LoadWords"
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	var words []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return words, nil
+}
+
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.TrimSpace(s))
+}
@@ -0,0 +1,73 @@
+package aliaspolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	p, err := New(Options{
+		MinLength: 3,
+		MaxLength: 8,
+		Reserved:  []string{"api", "Admin"},
+		Blocked:   []string{"darn"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		alias        string
+		validLength  bool
+		validCharset bool
+		isReserved   bool
+	}{
+		{name: "valid", alias: "my-link", validLength: true, validCharset: true},
+		{name: "too short", alias: "ab", validCharset: true},
+		{name: "too long", alias: "abcdefghi", validCharset: true},
+		{name: "slash", alias: "a/b/c", validLength: true},
+		{name: "unicode confusable", alias: "аpple", validLength: true},
+		{name: "reserved", alias: "api", validLength: true, validCharset: true, isReserved: true},
+		{name: "reserved ignoring case", alias: "ADMIN", validLength: true, validCharset: true, isReserved: true},
+		{name: "contains reserved", alias: "my-api", validLength: true, validCharset: true},
+		{name: "blocked", alias: "so-darn", validLength: true, validCharset: true, isReserved: true},
+		{name: "blocked with separators", alias: "D_a-rn", validLength: true, validCharset: true, isReserved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.validLength, p.ValidLength(tt.alias))
+			assert.Equal(t, tt.validCharset, p.ValidCharset(tt.alias))
+			assert.Equal(t, tt.isReserved, p.IsReserved(tt.alias))
+
+			if err := p.Validate(tt.alias); tt.validLength && tt.validCharset && !tt.isReserved {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidAlias)
+			}
+		})
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	_, err := New(Options{MinLength: 0, MaxLength: 10})
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	_, err = New(Options{MinLength: 10, MaxLength: 5})
+	require.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestLoadWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\nfoo\n\n  bar \n"), 0o644))
+
+	words, err := LoadWords(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, words)
+
+	_, err = LoadWords(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}
@@ -8,8 +8,17 @@ import (
 )
 
 type Response struct {
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a single failed validation rule, so clients don't have to parse error messages.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
 }
 
 const (
@@ -56,27 +65,51 @@ func UnauthorizedError() Response {
 }
 
 func ValidationError(errs validator.ValidationErrors) Response {
-	var errMsgs []string
+	var (
+		errMsgs     []string
+		fieldErrors []FieldError
+	)
 
 	for _, err := range errs {
+		var msg string
+
 		switch err.ActualTag() {
 		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
+			msg = fmt.Sprintf("field %s is a required field", err.Field())
 		case "url":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+			msg = fmt.Sprintf("field %s is not a valid URL", err.Field())
 		case "min":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be longer than %s symbols", err.Field(), err.Param()))
+			msg = fmt.Sprintf("field %s must be longer than %s symbols", err.Field(), err.Param())
 		case "max":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be smaller than %s symbols", err.Field(), err.Param()))
+			msg = fmt.Sprintf("field %s must be smaller than %s symbols", err.Field(), err.Param())
+		case "gt":
+			msg = fmt.Sprintf("field %s must be greater than %s", err.Field(), err.Param())
+		case "oneof":
+			msg = fmt.Sprintf("field %s must be one of %s", err.Field(), err.Param())
 		case "containsany":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s must contains any of special character", err.Field()))
+			msg = fmt.Sprintf("field %s must contains any of special character", err.Field())
+		case "alias_charset":
+			msg = fmt.Sprintf("field %s contains not allowed characters", err.Field())
+		case "alias_reserved":
+			msg = fmt.Sprintf("field %s is reserved or contains blocked word", err.Field())
+		case "iso3166_1_alpha2", "iso3166_2":
+			msg = fmt.Sprintf("field %s is not a valid ISO 3166 code", err.Field())
 		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
+			msg = fmt.Sprintf("field %s is not valid", err.Field())
 		}
+
+		errMsgs = append(errMsgs, msg)
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   err.Field(),
+			Rule:    err.Tag(),
+			Param:   err.Param(),
+			Message: msg,
+		})
 	}
 
 	return Response{
 		Status: StatusError,
 		Error:  strings.Join(errMsgs, ", "),
+		Errors: fieldErrors,
 	}
 }
@@ -0,0 +1,130 @@
+// Package geoip resolves client ip to country and region using a local MaxMind-format (.mmdb) database.
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ErrInvalidIP = errors.New("invalid ip")
+
+// Location is a place of the ip. Country is ISO 3166-1 alpha-2 code, Region is ISO 3166-2 code of
+// the top level subdivision, e.g. US-WA. Fields are empty if the database doesn't know them.
+type Location struct {
+	Country string
+	Region  string
+}
+
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+// DB is a database file opened from disk. It can be replaced on disk while in use, Reload picks up
+// the new file without interrupting lookups.
+type DB struct {
+	path string
+
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+	size    int64
+}
+
+// Open opens the database file. Country and City databases of GeoIP2 and GeoLite2 are supported.
+func Open(path string) (*DB, error) {
+	const op = "lib.geoip.Open"
+
+	db := &DB{path: path}
+	if _, err := db.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return db, nil
+}
+
+// Lookup returns location of the ip. Unknown ips have empty location. Nil database knows no ips,
+// so it can be used when geoip is disabled.
+func (db *DB) Lookup(ip net.IP) (Location, error) {
+	const op = "lib.geoip.DB.Lookup"
+
+	if db == nil {
+		return Location{}, nil
+	}
+
+	if ip == nil {
+		return Location{}, fmt.Errorf("%s: %w", op, ErrInvalidIP)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var rec record
+	if err := db.reader.Lookup(ip, &rec); err != nil {
+		return Location{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	loc := Location{Country: rec.Country.ISOCode}
+	if loc.Country != "" && len(rec.Subdivisions) != 0 && rec.Subdivisions[0].ISOCode != "" {
+		loc.Region = loc.Country + "-" + rec.Subdivisions[0].ISOCode
+	}
+
+	return loc, nil
+}
+
+// Reload opens the database file again if it has changed since the last load. The old file stays
+// in use if the new one can't be opened. It reports whether the database has been reloaded.
+func (db *DB) Reload() (bool, error) {
+	const op = "lib.geoip.DB.Reload"
+
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db.mu.RLock()
+	changed := db.reader == nil || !info.ModTime().Equal(db.modTime) || info.Size() != db.size
+	db.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	// the file is read into memory instead of mmap, so it can be safely replaced or truncated on disk
+	data, err := os.ReadFile(db.path)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db.mu.Lock()
+	old := db.reader
+	db.reader, db.modTime, db.size = reader, info.ModTime(), info.Size()
+	db.mu.Unlock()
+
+	if old != nil {
+		_ = old.Close()
+	}
+
+	return true, nil
+}
+
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.reader.Close()
+}
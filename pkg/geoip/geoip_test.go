@@ -0,0 +1,72 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fixture = "testdata/GeoIP2-City-Test.mmdb"
+
+func TestLookup(t *testing.T) {
+	db, err := Open(fixture)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{ip: "81.2.69.160", want: Location{Country: "GB", Region: "GB-ENG"}},
+		{ip: "216.160.83.56", want: Location{Country: "US", Region: "US-WA"}},
+		{ip: "2001:db8::1", want: Location{Country: "DE", Region: "DE-BE"}},
+		{ip: "127.0.0.1", want: Location{}},
+	}
+
+	for _, tc := range tests {
+		loc, err := db.Lookup(net.ParseIP(tc.ip))
+		require.NoError(t, err, tc.ip)
+		assert.Equal(t, tc.want, loc, tc.ip)
+	}
+
+	_, err = db.Lookup(nil)
+	assert.ErrorIs(t, err, ErrInvalidIP)
+}
+
+func TestReload(t *testing.T) {
+	data, err := os.ReadFile(fixture)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "geo.mmdb")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	reloaded, err := db.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "file hasn't changed")
+
+	require.NoError(t, os.WriteFile(path, []byte("broken"), 0o644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+	_, err = db.Reload()
+	require.Error(t, err)
+
+	loc, err := db.Lookup(net.ParseIP("81.2.69.160"))
+	require.NoError(t, err)
+	assert.Equal(t, "GB", loc.Country, "old database is used until the new one is valid")
+
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+
+	reloaded, err = db.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+}
@@ -0,0 +1,269 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Key is an asymmetric key signing access tokens. The key signs tokens from ActiveFrom until RetireAt,
+// tokens signed with it are accepted until RetireAt plus grace period of the key set.
+type Key struct {
+	ID         string
+	Algorithm  string
+	ActiveFrom time.Time
+	// RetireAt is zero if the key isn't scheduled to retire.
+	RetireAt time.Time
+
+	private crypto.Signer
+}
+
+// Public returns the public key verifying tokens signed with the key.
+func (k *Key) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+func (k *Key) signingMethod() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+func (k *Key) isActive(now time.Time) bool {
+	return !now.Before(k.ActiveFrom) && (k.RetireAt.IsZero() || now.Before(k.RetireAt))
+}
+
+func (k *Key) isValid(now time.Time, grace time.Duration) bool {
+	return k.RetireAt.IsZero() || now.Before(k.RetireAt.Add(grace))
+}
+
+// KeySet is a set of signing keys identified by kid. Several keys can be valid at once: the newest active key
+// signs tokens, the keys scheduled for the future are published in advance and retired keys stay valid
+// for the grace period, so clients caching JWKS have time to pick the changes up.
+type KeySet struct {
+	keys  []*Key
+	grace time.Duration
+
+	now func() time.Time
+}
+
+type keysManifest struct {
+	Keys []struct {
+		ID             string    `json:"kid"`
+		Algorithm      string    `json:"alg"`
+		PrivateKeyFile string    `json:"private_key_file"`
+		ActiveFrom     time.Time `json:"active_from"`
+		RetireAt       time.Time `json:"retire_at"`
+	} `json:"keys"`
+}
+
+// LoadKeySet loads keys listed in the json manifest:
+//
+//	{"keys": [{"kid": "2024-07", "alg": "ES256", "private_key_file": "2024-07.pem",
+//	  "active_from": "2024-07-01T00:00:00Z", "retire_at": "2024-08-01T00:00:00Z"}]}
+//
+// Paths of PEM files are relative to the manifest. Grace is how long tokens of retired keys are accepted,
+// it should be not less than access token TTL.
+func LoadKeySet(manifestPath string, grace time.Duration) (*KeySet, error) {
+	const op = "lib.token-manager.keys.LoadKeySet"
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var manifest keysManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := make([]*Key, 0, len(manifest.Keys))
+	for _, item := range manifest.Keys {
+		path := item.PrivateKeyFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(manifestPath), path)
+		}
+
+		pemData, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", op, item.ID, err)
+		}
+
+		private, err := parsePrivateKey(item.Algorithm, pemData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", op, item.ID, err)
+		}
+
+		keys = append(keys, &Key{
+			ID:         item.ID,
+			Algorithm:  item.Algorithm,
+			ActiveFrom: item.ActiveFrom,
+			RetireAt:   item.RetireAt,
+			private:    private,
+		})
+	}
+
+	set, err := NewKeySet(keys, grace)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return set, nil
+}
+
+// NewKeySet checks the keys and returns the set of them.
+func NewKeySet(keys []*Key, grace time.Duration) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys")
+	}
+
+	ids := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("key without kid")
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("duplicate kid %q", key.ID)
+		}
+		ids[key.ID] = true
+
+		if err := checkKeyType(key.Algorithm, key.private); err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.ID, err)
+		}
+		if !key.RetireAt.IsZero() && !key.RetireAt.After(key.ActiveFrom) {
+			return nil, fmt.Errorf("key %q: retire_at must be after active_from", key.ID)
+		}
+	}
+
+	sorted := make([]*Key, len(keys))
+	copy(sorted, keys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom)
+	})
+
+	return &KeySet{
+		keys:  sorted,
+		grace: grace,
+		now:   time.Now,
+	}, nil
+}
+
+// NewKey returns a key with the private key. It's used to build key sets without files.
+func NewKey(id string, algorithm string, private crypto.Signer, activeFrom time.Time, retireAt time.Time) *Key {
+	return &Key{
+		ID:         id,
+		Algorithm:  algorithm,
+		ActiveFrom: activeFrom,
+		RetireAt:   retireAt,
+		private:    private,
+	}
+}
+
+// SigningKey returns the newest active key.
+func (s *KeySet) SigningKey() (*Key, error) {
+	now := s.now()
+
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].isActive(now) {
+			return s.keys[i], nil
+		}
+	}
+
+	return nil, ErrNoSigningKey
+}
+
+// VerificationKey returns the key with the kid if tokens signed with it are still accepted.
+func (s *KeySet) VerificationKey(kid string) (*Key, error) {
+	now := s.now()
+
+	for _, key := range s.keys {
+		if key.ID == kid && key.isValid(now, s.grace) {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// PublicKeys returns keys that are valid now or will be valid in the future.
+func (s *KeySet) PublicKeys() []*Key {
+	now := s.now()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		if key.isValid(now, s.grace) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+func parsePrivateKey(algorithm string, pemData []byte) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return jwt.ParseRSAPrivateKeyFromPEM(pemData)
+	case AlgorithmES256:
+		return jwt.ParseECPrivateKeyFromPEM(pemData)
+	case AlgorithmEdDSA:
+		key, err := jwt.ParseEdPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return nil, err
+		}
+
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+}
+
+func checkKeyType(algorithm string, private crypto.Signer) error {
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != AlgorithmRS256 {
+			return fmt.Errorf("rsa key can't be used with %q", algorithm)
+		}
+		if key.N.BitLen() < 2048 {
+			return errors.New("rsa key must be at least 2048 bits")
+		}
+	case *ecdsa.PrivateKey:
+		if algorithm != AlgorithmES256 {
+			return fmt.Errorf("ecdsa key can't be used with %q", algorithm)
+		}
+		if key.Curve != elliptic.P256() {
+			return errors.New("ES256 requires P-256 key")
+		}
+	case ed25519.PrivateKey:
+		if algorithm != AlgorithmEdDSA {
+			return fmt.Errorf("ed25519 key can't be used with %q", algorithm)
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", private)
+	}
+
+	return nil
+}
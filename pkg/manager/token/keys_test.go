@@ -0,0 +1,204 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSigner(t *testing.T, algorithm string) crypto.Signer {
+	t.Helper()
+
+	var (
+		signer crypto.Signer
+		err    error
+	)
+	switch algorithm {
+	case AlgorithmRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+	require.NoError(t, err)
+
+	return signer
+}
+
+// writeManifest writes PEM file of the key and the manifest with it to the temp dir.
+func writeManifest(t *testing.T, kid string, algorithm string, signer crypto.Signer) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	require.NoError(t, err)
+
+	keyData := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), keyData, 0o600))
+
+	manifest, err := json.Marshal(map[string]any{
+		"keys": []map[string]any{{
+			"kid":              kid,
+			"alg":              algorithm,
+			"private_key_file": kid + ".pem",
+			"active_from":      time.Now().Add(-time.Hour),
+		}},
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(path, manifest, 0o600))
+
+	return path
+}
+
+func TestManagerWithKeys(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		keyType   string
+	}{
+		{algorithm: AlgorithmRS256, keyType: "RSA"},
+		{algorithm: AlgorithmES256, keyType: "EC"},
+		{algorithm: AlgorithmEdDSA, keyType: "OKP"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.algorithm, func(t *testing.T) {
+			t.Parallel()
+
+			keys, err := LoadKeySet(writeManifest(t, "key-1", tc.algorithm, newTestSigner(t, tc.algorithm)), time.Minute)
+			require.NoError(t, err)
+
+			m := NewManagerWithKeys("secret", keys, time.Time{})
+
+			tokens, err := m.CreateTokensPair("1", time.Minute)
+			require.NoError(t, err)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, jwt.MapClaims{})
+			require.NoError(t, err)
+			require.Equal(t, "key-1", token.Header["kid"])
+			require.Equal(t, tc.algorithm, token.Header["alg"])
+
+			claims, err := m.Parse(tokens.AccessToken)
+			require.NoError(t, err)
+			require.Equal(t, "1", claims["user_id"])
+
+			jwks := m.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			require.Equal(t, "key-1", jwks.Keys[0].KeyID)
+			require.Equal(t, tc.keyType, jwks.Keys[0].KeyType)
+			require.Equal(t, tc.algorithm, jwks.Keys[0].Algorithm)
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	now := time.Now()
+
+	keys := []*Key{
+		NewKey("retired", AlgorithmES256, newTestSigner(t, AlgorithmES256), now.Add(-3*time.Hour), now.Add(-time.Hour)),
+		NewKey("old", AlgorithmES256, newTestSigner(t, AlgorithmES256), now.Add(-2*time.Hour), now.Add(-10*time.Minute)),
+		NewKey("current", AlgorithmEdDSA, newTestSigner(t, AlgorithmEdDSA), now.Add(-time.Hour), time.Time{}),
+		NewKey("next", AlgorithmES256, newTestSigner(t, AlgorithmES256), now.Add(time.Hour), time.Time{}),
+	}
+
+	set, err := NewKeySet(keys, 30*time.Minute)
+	require.NoError(t, err)
+
+	signing, err := set.SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, "current", signing.ID)
+
+	_, err = set.VerificationKey("old")
+	require.NoError(t, err, "retired key is valid during grace period")
+
+	_, err = set.VerificationKey("retired")
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	var published []string
+	for _, key := range set.PublicKeys() {
+		published = append(published, key.ID)
+	}
+	require.Equal(t, []string{"old", "current", "next"}, published)
+
+	// the next key starts signing when it becomes active
+	set.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+	signing, err = set.SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, "next", signing.ID)
+}
+
+func TestNewKeySetInvalid(t *testing.T) {
+	signer := newTestSigner(t, AlgorithmES256)
+
+	tests := []struct {
+		name string
+		keys []*Key
+	}{
+		{name: "no keys"},
+		{name: "empty kid", keys: []*Key{NewKey("", AlgorithmES256, signer, time.Time{}, time.Time{})}},
+		{name: "duplicate kid", keys: []*Key{
+			NewKey("a", AlgorithmES256, signer, time.Time{}, time.Time{}),
+			NewKey("a", AlgorithmES256, signer, time.Time{}, time.Time{}),
+		}},
+		{name: "algorithm mismatch", keys: []*Key{NewKey("a", AlgorithmRS256, signer, time.Time{}, time.Time{})}},
+		{name: "retired before active", keys: []*Key{NewKey("a", AlgorithmES256, signer, time.Now(), time.Now().Add(-time.Hour))}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewKeySet(tc.keys, time.Minute)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestManagerHS256Fallback(t *testing.T) {
+	tokens, err := NewManager("secret").CreateTokensPair("1", time.Minute)
+	require.NoError(t, err)
+
+	keys, err := NewKeySet([]*Key{
+		NewKey("key-1", AlgorithmEdDSA, newTestSigner(t, AlgorithmEdDSA), time.Now().Add(-time.Hour), time.Time{}),
+	}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = NewManagerWithKeys("secret", keys, time.Now().Add(time.Minute)).Parse(tokens.AccessToken)
+	require.NoError(t, err, "HS256 tokens issued before the switch are accepted")
+
+	_, err = NewManagerWithKeys("secret", keys, time.Now().Add(-time.Second)).Parse(tokens.AccessToken)
+	require.Error(t, err, "HS256 tokens are rejected after the cutoff")
+
+	_, err = NewManagerWithKeys("secret", keys, time.Time{}).Parse(tokens.AccessToken)
+	require.Error(t, err, "HS256 tokens are rejected without cutoff")
+
+	// a token signed with the secret must not pass as a token of the key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "1", "exp": time.Now().Add(time.Minute).Unix()})
+	forged.Header["kid"] = "key-1"
+	forgedToken, err := forged.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = NewManagerWithKeys("secret", keys, time.Now().Add(time.Minute)).Parse(forgedToken)
+	require.Error(t, err)
+}
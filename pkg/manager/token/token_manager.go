@@ -1,8 +1,10 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
@@ -11,10 +13,17 @@ import (
 type TokenManager interface {
 	CreateTokensPair(userId string, ttl time.Duration) (*Tokens, error)
 	Parse(accessToken string) (jwt.MapClaims, error)
+	JWKS() JWKS
 }
 
+// Manager signs access tokens with HS256 and the secret, or with asymmetric keys if they are set.
 type Manager struct {
 	secret string
+
+	keys *KeySet
+	// hs256Until is the time HS256 tokens are accepted until after switching to keys,
+	// so tokens issued before the switch stay valid until they expire.
+	hs256Until time.Time
 }
 
 type Tokens struct {
@@ -27,6 +36,17 @@ func NewManager(secret string) *Manager {
 	return &Manager{secret: secret}
 }
 
+// NewManagerWithKeys returns the manager signing access tokens with the keys. HS256 tokens signed
+// with the secret are accepted until hs256Until, zero time rejects them at once. The cutoff is a fixed
+// time rather than a period since start, otherwise every restart would accept HS256 tokens again.
+func NewManagerWithKeys(secret string, keys *KeySet, hs256Until time.Time) *Manager {
+	return &Manager{
+		secret:     secret,
+		keys:       keys,
+		hs256Until: hs256Until,
+	}
+}
+
 func (m *Manager) CreateTokensPair(userId string, ttl time.Duration) (*Tokens, error) {
 	const op = "lib.token-manager.token_manager.createTokensPair"
 
@@ -50,13 +70,7 @@ func (m *Manager) CreateTokensPair(userId string, ttl time.Duration) (*Tokens, e
 func (m *Manager) Parse(accessToken string) (jwt.MapClaims, error) {
 	const op = "lib.token-manager.token_manager.Parse"
 
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (i interface{}, err error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		return []byte(m.secret), nil
-	})
+	token, err := jwt.Parse(accessToken, m.verificationKey)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -69,15 +83,83 @@ func (m *Manager) Parse(accessToken string) (jwt.MapClaims, error) {
 	return claims, nil
 }
 
+// JWKS returns public keys verifying access tokens. It's empty if tokens are signed with the secret.
+func (m *Manager) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	if m.keys == nil {
+		return jwks
+	}
+
+	for _, key := range m.keys.PublicKeys() {
+		jwks.Keys = append(jwks.Keys, newJWK(key))
+	}
+
+	return jwks
+}
+
+// verificationKey returns the key verifying the token: the key with kid of the token or the secret
+// for HS256 tokens without kid.
+func (m *Manager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if kid, ok := token.Header["kid"].(string); ok && m.keys != nil {
+		key, err := m.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return key.Public(), nil
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	if m.keys != nil && !time.Now().Before(m.hs256Until) {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return []byte(m.secret), nil
+}
+
 func (m *Manager) newJWT(userId string, ttl time.Duration) (string, error) {
-	const op = "lib.token-manager.token_manager.Parse"
+	const op = "lib.token-manager.token_manager.newJWT"
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	jti, err := newTokenId()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
 		"user_id": userId,
-		"exp":     time.Now().Add(ttl).Unix(),
-	})
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     now.Add(ttl).Unix(),
+	}
 
-	completeToken, err := token.SignedString([]byte(m.secret))
+	if m.keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+		completeToken, err := token.SignedString([]byte(m.secret))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		return completeToken, nil
+	}
+
+	key, err := m.keys.SigningKey()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	token := jwt.NewWithClaims(key.signingMethod(), claims)
+	token.Header["kid"] = key.ID
+
+	completeToken, err := token.SignedString(key.private)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
@@ -85,17 +167,32 @@ func (m *Manager) newJWT(userId string, ttl time.Duration) (string, error) {
 	return completeToken, nil
 }
 
+// newTokenId returns random jti, so the access token can be revoked before it expires.
+func newTokenId() (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
 func (m *Manager) newRefreshToken() (string, error) {
 	const op = "lib.token-manager.token_manager.newRefreshToken"
 
 	b := make([]byte, 32)
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
-
-	if _, err := r.Read(b); err != nil {
+	if _, err := rand.Read(b); err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	return fmt.Sprintf("%x", b), nil
+	return hex.EncodeToString(b), nil
+}
+
+// HashRefreshToken returns SHA-256 digest of the refresh token. Only digests are stored, so leaked sessions
+// can't be used to refresh tokens. Refresh tokens are random, so they don't need a slow hash with salt.
+func HashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
 }
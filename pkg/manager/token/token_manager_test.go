@@ -0,0 +1,52 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTokensPairUniqueRefreshTokens(t *testing.T) {
+	m := NewManager("secret")
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		tokens, err := m.CreateTokensPair("1", time.Minute)
+		require.NoError(t, err)
+
+		require.Len(t, tokens.RefreshToken, 64)
+		require.False(t, seen[tokens.RefreshToken], "refresh token is repeated")
+		seen[tokens.RefreshToken] = true
+	}
+}
+
+func TestHashRefreshToken(t *testing.T) {
+	hash := HashRefreshToken("token")
+
+	require.Equal(t, "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0", hash)
+	require.Equal(t, hash, HashRefreshToken("token"))
+	require.NotEqual(t, hash, HashRefreshToken("token2"))
+}
+
+func TestCreateTokensPairAccessTokenId(t *testing.T) {
+	m := NewManager("secret")
+
+	first, err := m.CreateTokensPair("1", time.Minute)
+	require.NoError(t, err)
+	second, err := m.CreateTokensPair("1", time.Minute)
+	require.NoError(t, err)
+
+	firstClaims, err := m.Parse(first.AccessToken)
+	require.NoError(t, err)
+	secondClaims, err := m.Parse(second.AccessToken)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, firstClaims["jti"])
+	require.NotEqual(t, firstClaims["jti"], secondClaims["jti"])
+
+	issuedAt, err := firstClaims.GetIssuedAt()
+	require.NoError(t, err)
+	require.NotNil(t, issuedAt)
+	require.WithinDuration(t, time.Now(), issuedAt.Time, 2*time.Second)
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// FailureLimiter limits failed attempts per key, e.g. wrong passwords per ip. Every key may fail max times
+// within a window, the window starts on the first attempt. Successful attempts should reset the key.
+type FailureLimiter struct {
+	mu sync.Mutex
+
+	max    int
+	window time.Duration
+
+	failures  map[string]*failures
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type failures struct {
+	count int
+	start time.Time
+}
+
+func NewFailureLimiter(max int, window time.Duration) *FailureLimiter {
+	return &FailureLimiter{
+		max:       max,
+		window:    window,
+		failures:  make(map[string]*failures),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Attempt reserves an attempt of the key and reports whether it's allowed. The attempt counts as failed
+// until the key is reset, so concurrent attempts can't get past the limit before their failures are
+// registered.
+func (l *FailureLimiter) Attempt(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep()
+
+	f, ok := l.failures[key]
+	if !ok || l.expired(f) {
+		l.failures[key] = &failures{count: 1, start: l.now()}
+		return true
+	}
+
+	if f.count >= l.max {
+		return false
+	}
+
+	f.count++
+	return true
+}
+
+// Reset forgets failed attempts of the key.
+func (l *FailureLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, key)
+}
+
+func (l *FailureLimiter) expired(f *failures) bool {
+	return l.now().Sub(f.start) >= l.window
+}
+
+// sweep removes expired keys once per window, so the map doesn't grow with keys that never come back.
+func (l *FailureLimiter) sweep() {
+	if l.now().Sub(l.lastSweep) < l.window {
+		return
+	}
+
+	for key, f := range l.failures {
+		if l.expired(f) {
+			delete(l.failures, key)
+		}
+	}
+
+	l.lastSweep = l.now()
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureLimiterAttempt(t *testing.T) {
+	now := time.Now()
+
+	l := NewFailureLimiter(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Attempt("a"))
+	assert.True(t, l.Attempt("a"))
+	assert.False(t, l.Attempt("a"), "attempts are counted before they fail")
+	assert.False(t, l.Attempt("a"), "refused attempts don't reset the limit")
+	assert.True(t, l.Attempt("b"), "keys are limited independently")
+
+	l.Reset("a")
+	assert.True(t, l.Attempt("a"))
+	assert.True(t, l.Attempt("a"))
+	assert.False(t, l.Attempt("a"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, l.Attempt("a"), "window has passed")
+	assert.True(t, l.Attempt("b"), "window has passed")
+}
+
+func TestFailureLimiterConcurrentAttempts(t *testing.T) {
+	l := NewFailureLimiter(5, time.Minute)
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Attempt("a") {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(5), allowed.Load())
+}
+
+func TestFailureLimiterSweep(t *testing.T) {
+	now := time.Now()
+
+	l := NewFailureLimiter(1, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Attempt("a")
+	now = now.Add(2 * time.Minute)
+	l.Attempt("b")
+
+	assert.NotContains(t, l.failures, "a")
+	assert.Contains(t, l.failures, "b")
+}
@@ -0,0 +1,102 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+// Store keeps revoked access tokens in memory until they expire. A single token is revoked by jti,
+// all tokens of a user are revoked by the time they were issued before.
+type Store struct {
+	mu sync.RWMutex
+
+	tokens map[string]time.Time
+	users  map[string]userRevocation
+
+	now func() time.Time
+}
+
+type userRevocation struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		tokens: make(map[string]time.Time),
+		users:  make(map[string]userRevocation),
+		now:    time.Now,
+	}
+}
+
+// RevokeToken revokes the token with jti until expiresAt, it's the time the token expires anyway.
+func (s *Store) RevokeToken(jti string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.tokens[jti]; ok && current.After(expiresAt) {
+		return
+	}
+
+	s.tokens[jti] = expiresAt
+}
+
+// Cutoff returns the cutoff revoking tokens issued until now. iat has seconds precision, so it's the next
+// whole second: tokens issued within the current second are revoked too, and the caller must not issue
+// new tokens to the user until the cutoff, otherwise they're revoked at once.
+func Cutoff(now time.Time) time.Time {
+	return now.Truncate(time.Second).Add(time.Second)
+}
+
+// RevokeUser revokes tokens of the user issued before the cutoff, see Cutoff. The revocation is kept
+// until expiresAt, when all of these tokens are expired. The latest revocation of the user wins.
+func (s *Store) RevokeUser(userId string, before time.Time, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.users[userId]; ok && current.before.After(before) {
+		return
+	}
+
+	s.users[userId] = userRevocation{before: before, expiresAt: expiresAt}
+}
+
+// IsRevoked reports whether the token is revoked. issuedAt is zero for tokens without iat,
+// they're revoked with any revocation of the user.
+func (s *Store) IsRevoked(jti string, userId string, issuedAt time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+
+	if expiresAt, ok := s.tokens[jti]; ok && jti != "" && now.Before(expiresAt) {
+		return true
+	}
+
+	user, ok := s.users[userId]
+	if !ok || !now.Before(user.expiresAt) {
+		return false
+	}
+
+	return issuedAt.Before(user.before)
+}
+
+// Cleanup removes expired revocations.
+func (s *Store) Cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	for jti, expiresAt := range s.tokens {
+		if !now.Before(expiresAt) {
+			delete(s.tokens, jti)
+		}
+	}
+
+	for userId, user := range s.users {
+		if !now.Before(user.expiresAt) {
+			delete(s.users, userId)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreRevokeToken(t *testing.T) {
+	now := time.Now()
+
+	s := NewStore()
+	s.now = func() time.Time { return now }
+
+	s.RevokeToken("a", now.Add(time.Minute))
+
+	assert.True(t, s.IsRevoked("a", "1", now))
+	assert.False(t, s.IsRevoked("b", "1", now), "other tokens aren't revoked")
+	assert.False(t, s.IsRevoked("", "1", now), "tokens without jti aren't revoked by jti")
+
+	now = now.Add(time.Minute)
+	assert.False(t, s.IsRevoked("a", "1", now), "revocation has expired")
+
+	s.Cleanup()
+	assert.Empty(t, s.tokens)
+}
+
+func TestStoreRevokeUser(t *testing.T) {
+	now := time.Date(2024, 7, 24, 12, 0, 0, 500_000_000, time.UTC)
+
+	s := NewStore()
+	s.now = func() time.Time { return now }
+
+	cutoff := Cutoff(now)
+	assert.Equal(t, time.Date(2024, 7, 24, 12, 0, 1, 0, time.UTC), cutoff)
+
+	s.RevokeUser("1", cutoff, now.Add(time.Hour))
+
+	assert.True(t, s.IsRevoked("a", "1", now.Add(-time.Minute)))
+	assert.True(t, s.IsRevoked("a", "1", now.Truncate(time.Second)), "tokens issued within the second are revoked")
+	assert.True(t, s.IsRevoked("a", "1", time.Time{}), "tokens without iat are revoked")
+	assert.False(t, s.IsRevoked("a", "1", cutoff), "tokens issued since the cutoff are valid")
+	assert.False(t, s.IsRevoked("a", "2", now.Add(-time.Minute)), "other users aren't revoked")
+
+	s.RevokeUser("1", now.Add(-time.Minute), now.Add(time.Hour))
+	assert.True(t, s.IsRevoked("a", "1", now.Add(-time.Second)), "earlier revocation doesn't override the latest one")
+
+	now = now.Add(time.Hour)
+	assert.False(t, s.IsRevoked("a", "1", now.Add(-2*time.Hour)), "revocation has expired")
+
+	s.Cleanup()
+	assert.Empty(t, s.users)
+}
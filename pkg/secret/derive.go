@@ -0,0 +1,28 @@
+package secret
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	PurposeJWT         = "url-shortener/jwt-hs256"
+	PurposeURLUnlock   = "url-shortener/url-unlock"
+	PurposeVisitorHash = "url-shortener/visitor-hash"
+)
+
+// Derive derives an independent key for the purpose from the master secret with HKDF-SHA256, so one
+// secret can be configured while a leak of a key used for one purpose doesn't reveal the others.
+func Derive(master string, purpose string) string {
+	key := make([]byte, sha256.Size)
+
+	// HKDF can't fail reading less than 255 hash sizes
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(master), nil, []byte(purpose)), key); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(key)
+}
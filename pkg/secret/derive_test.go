@@ -0,0 +1,17 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerive(t *testing.T) {
+	jwt := Derive("master", PurposeJWT)
+
+	assert.Len(t, jwt, 64)
+	assert.Equal(t, jwt, Derive("master", PurposeJWT), "keys are deterministic")
+	assert.NotEqual(t, jwt, Derive("master", PurposeURLUnlock), "purposes get different keys")
+	assert.NotEqual(t, jwt, Derive("other", PurposeJWT), "secrets get different keys")
+	assert.NotContains(t, jwt, "master")
+}
@@ -0,0 +1,110 @@
+// Package urlsafety rates destinations of short links by simple heuristics. It doesn't consult
+// any blocklist, so StatusOK only means that none of the known warning signs is found.
+package urlsafety
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+)
+
+const (
+	WarningInvalidURL   = "destination is not a valid url"
+	WarningNoTLS        = "destination doesn't use https"
+	WarningIPHost       = "destination host is an ip address"
+	WarningPunycode     = "destination domain contains non-latin characters that can imitate another domain"
+	WarningCredentials  = "destination contains credentials that can hide its real host"
+	WarningPort         = "destination uses non-standard port"
+	WarningShortener    = "destination is another short link"
+	WarningNotWebScheme = "destination is not a web page"
+)
+
+// shorteners are hosts of popular url shorteners, chains of short links hide the final destination.
+var shorteners = map[string]bool{
+	"bit.ly":      true,
+	"t.co":        true,
+	"tinyurl.com": true,
+	"goo.gl":      true,
+	"ow.ly":       true,
+	"is.gd":       true,
+	"buff.ly":     true,
+	"cutt.ly":     true,
+	"rebrand.ly":  true,
+	"shorturl.at": true,
+}
+
+type Report struct {
+	Status   string
+	Warnings []string
+}
+
+// Check rates the destination.
+func Check(rawURL string) Report {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return newReport(WarningInvalidURL)
+	}
+
+	var warnings []string
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		warnings = append(warnings, WarningNoTLS)
+	default:
+		warnings = append(warnings, WarningNotWebScheme)
+	}
+
+	if u.User != nil {
+		warnings = append(warnings, WarningCredentials)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil {
+		warnings = append(warnings, WarningIPHost)
+	}
+
+	if internationalHost(host) {
+		warnings = append(warnings, WarningPunycode)
+	}
+
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		warnings = append(warnings, WarningPort)
+	}
+
+	if shorteners[strings.TrimPrefix(host, "www.")] {
+		warnings = append(warnings, WarningShortener)
+	}
+
+	return newReport(warnings...)
+}
+
+// internationalHost reports whether the host has non-latin characters, either as is or encoded in punycode.
+func internationalHost(host string) bool {
+	for i := 0; i < len(host); i++ {
+		if host[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if strings.HasPrefix(label, "xn--") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newReport(warnings ...string) Report {
+	if len(warnings) == 0 {
+		return Report{Status: StatusOK}
+	}
+	return Report{Status: StatusWarning, Warnings: warnings}
+}
@@ -0,0 +1,76 @@
+package urlsafety
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want Report
+	}{
+		{
+			name: "https",
+			url:  "https://example.com/page?a=1",
+			want: Report{Status: StatusOK},
+		},
+		{
+			name: "http",
+			url:  "http://example.com",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningNoTLS}},
+		},
+		{
+			name: "ip and port",
+			url:  "https://192.168.0.1:8443/login",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningIPHost, WarningPort}},
+		},
+		{
+			name: "credentials hiding host",
+			url:  "https://paypal.com@evil.example/",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningCredentials}},
+		},
+		{
+			name: "punycode",
+			url:  "https://xn--pple-43d.com",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningPunycode}},
+		},
+		{
+			name: "cyrillic letter in host",
+			url:  "https://p\u0430ypal.com",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningPunycode}},
+		},
+		{
+			name: "unicode subdomain",
+			url:  "https://www.\u0430pple.com/login",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningPunycode}},
+		},
+		{
+			name: "another shortener",
+			url:  "https://www.bit.ly/abc",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningShortener}},
+		},
+		{
+			name: "not web",
+			url:  "ftp://example.com/file",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningNotWebScheme}},
+		},
+		{
+			name: "invalid",
+			url:  "not a url",
+			want: Report{Status: StatusWarning, Warnings: []string{WarningInvalidURL}},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, Check(tc.url))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package useragent extracts os, device type and browser from User-Agent header. It relies on
+// well-known tokens of popular clients rather than on a full database, so unknown clients are
+// reported as "other".
+package useragent
+
+import "strings"
+
+const (
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+	OSOther    = "other"
+)
+
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+const (
+	BrowserChrome  = "chrome"
+	BrowserSafari  = "safari"
+	BrowserFirefox = "firefox"
+	BrowserEdge    = "edge"
+	BrowserOpera   = "opera"
+	BrowserSamsung = "samsung"
+	BrowserOther   = "other"
+)
+
+// botTokens are lowercase substrings of User-Agent of crawlers, link previewers and http libraries.
+var botTokens = []string{
+	"bot", "crawler", "spider", "slurp", "facebookexternalhit", "preview", "headless",
+	"curl/", "wget/", "python-requests", "go-http-client", "okhttp", "java/",
+}
+
+type Info struct {
+	OS      string
+	Device  string
+	Browser string
+	// Bot is true for crawlers, link previewers, http libraries and requests without User-Agent.
+	Bot bool
+}
+
+// Parse parses User-Agent header value.
+func Parse(ua string) Info {
+	return Info{
+		OS:      parseOS(ua),
+		Device:  parseDevice(ua),
+		Browser: parseBrowser(ua),
+		Bot:     isBot(ua),
+	}
+}
+
+func parseOS(ua string) string {
+	switch {
+	case containsAny(ua, "iPhone", "iPad", "iPod"):
+		return OSIOS
+	case strings.Contains(ua, "Android"):
+		return OSAndroid
+	case strings.Contains(ua, "Windows"):
+		return OSWindows
+	case strings.Contains(ua, "CrOS"):
+		return OSChromeOS
+	case containsAny(ua, "Macintosh", "Mac OS X"):
+		return OSMacOS
+	case strings.Contains(ua, "Linux"):
+		return OSLinux
+	default:
+		return OSOther
+	}
+}
+
+func parseDevice(ua string) string {
+	switch {
+	case containsAny(ua, "iPad", "Tablet"):
+		return DeviceTablet
+	case strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		// android tablets don't have Mobile token
+		return DeviceTablet
+	case containsAny(ua, "Mobi", "iPhone", "iPod"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// parseBrowser checks tokens from the most specific ones, because most browsers mention
+// Chrome and Safari in their User-Agent.
+func parseBrowser(ua string) string {
+	switch {
+	case containsAny(ua, "Edg/", "EdgA/", "EdgiOS/"):
+		return BrowserEdge
+	case containsAny(ua, "OPR/", "Opera"):
+		return BrowserOpera
+	case strings.Contains(ua, "SamsungBrowser/"):
+		return BrowserSamsung
+	case containsAny(ua, "Firefox/", "FxiOS/"):
+		return BrowserFirefox
+	case containsAny(ua, "Chrome/", "CriOS/"):
+		return BrowserChrome
+	case strings.Contains(ua, "Safari/"):
+		return BrowserSafari
+	default:
+		return BrowserOther
+	}
+}
+
+func isBot(ua string) bool {
+	if strings.TrimSpace(ua) == "" {
+		return true
+	}
+
+	ua = strings.ToLower(ua)
+	for _, token := range botTokens {
+		if strings.Contains(ua, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Info
+	}{
+		{
+			name: "iphone safari",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want: Info{OS: OSIOS, Device: DeviceMobile, Browser: BrowserSafari},
+		},
+		{
+			name: "ipad chrome",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/126.0.6478.54 Mobile/15E148 Safari/604.1",
+			want: Info{OS: OSIOS, Device: DeviceTablet, Browser: BrowserChrome},
+		},
+		{
+			name: "android phone chrome",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+			want: Info{OS: OSAndroid, Device: DeviceMobile, Browser: BrowserChrome},
+		},
+		{
+			name: "android tablet samsung browser",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/25.0 Chrome/121.0.0.0 Safari/537.36",
+			want: Info{OS: OSAndroid, Device: DeviceTablet, Browser: BrowserSamsung},
+		},
+		{
+			name: "windows edge",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+			want: Info{OS: OSWindows, Device: DeviceDesktop, Browser: BrowserEdge},
+		},
+		{
+			name: "macos firefox",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.5; rv:127.0) Gecko/20100101 Firefox/127.0",
+			want: Info{OS: OSMacOS, Device: DeviceDesktop, Browser: BrowserFirefox},
+		},
+		{
+			name: "linux opera",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 OPR/111.0.0.0",
+			want: Info{OS: OSLinux, Device: DeviceDesktop, Browser: BrowserOpera},
+		},
+		{
+			name: "chromebook",
+			ua:   "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want: Info{OS: OSChromeOS, Device: DeviceDesktop, Browser: BrowserChrome},
+		},
+		{
+			name: "googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Info{OS: OSOther, Device: DeviceDesktop, Browser: BrowserOther, Bot: true},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.6.0",
+			want: Info{OS: OSOther, Device: DeviceDesktop, Browser: BrowserOther, Bot: true},
+		},
+		{
+			name: "empty",
+			ua:   "",
+			want: Info{OS: OSOther, Device: DeviceDesktop, Browser: BrowserOther, Bot: true},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, Parse(tc.ua))
+		})
+	}
+}
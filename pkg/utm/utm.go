@@ -0,0 +1,103 @@
+package utm
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("invalid url")
+
+// Params are UTM parameters of a campaign. Empty fields aren't added to urls.
+type Params struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// IsEmpty reports whether no parameter is set.
+func (p Params) IsEmpty() bool {
+	return p == Params{}
+}
+
+// Or returns p with empty fields taken from defaults.
+func (p Params) Or(defaults Params) Params {
+	return Params{
+		Source:   or(p.Source, defaults.Source),
+		Medium:   or(p.Medium, defaults.Medium),
+		Campaign: or(p.Campaign, defaults.Campaign),
+		Term:     or(p.Term, defaults.Term),
+		Content:  or(p.Content, defaults.Content),
+	}
+}
+
+// Merge adds parameters to the query of the url, replacing UTM parameters the url already has.
+// Other query parameters and the fragment are kept byte for byte, so their order and escaping
+// don't change and pairs the url package can't parse, e.g. separated by ';', aren't lost.
+func Merge(rawURL string, p Params) (string, error) {
+	const op = "lib.utm.Merge"
+
+	if p.IsEmpty() {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %w", op, ErrInvalidURL, err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("%s: %w: url must be absolute", op, ErrInvalidURL)
+	}
+
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"utm_source":   p.Source,
+		"utm_medium":   p.Medium,
+		"utm_campaign": p.Campaign,
+		"utm_term":     p.Term,
+		"utm_content":  p.Content,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+
+	pairs := withoutKeys(u.RawQuery, params)
+	u.RawQuery = strings.Join(append(pairs, params.Encode()), "&")
+
+	return u.String(), nil
+}
+
+// withoutKeys splits the raw query into pairs and drops the ones with keys from params.
+// Pairs that can't be unescaped are compared as is.
+func withoutKeys(rawQuery string, params url.Values) []string {
+	if rawQuery == "" {
+		return nil
+	}
+
+	var res []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if _, ok := params[key]; ok {
+			continue
+		}
+		res = append(res, pair)
+	}
+
+	return res
+}
+
+func or(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
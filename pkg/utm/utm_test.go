@@ -0,0 +1,91 @@
+package utm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		params  Params
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "no params",
+			url:    "https://example.com/page?b=2&a=1",
+			params: Params{},
+			want:   "https://example.com/page?b=2&a=1",
+		},
+		{
+			name:   "params are added",
+			url:    "https://example.com/page",
+			params: Params{Source: "newsletter", Medium: "email", Campaign: "spring sale"},
+			want:   "https://example.com/page?utm_campaign=spring+sale&utm_medium=email&utm_source=newsletter",
+		},
+		{
+			name:   "existing query and fragment are kept",
+			url:    "https://example.com/page?id=7#details",
+			params: Params{Source: "x"},
+			want:   "https://example.com/page?id=7&utm_source=x#details",
+		},
+		{
+			name:   "existing utm params are replaced",
+			url:    "https://example.com/?utm_source=old&utm_medium=cpc",
+			params: Params{Source: "new"},
+			want:   "https://example.com/?utm_medium=cpc&utm_source=new",
+		},
+		{
+			name:   "order and escaping of the query are kept",
+			url:    "https://example.com/?z=1&a=%7e&utm_source=old&m=a+b",
+			params: Params{Source: "new"},
+			want:   "https://example.com/?z=1&a=%7e&m=a+b&utm_source=new",
+		},
+		{
+			name:   "semicolon pairs are kept",
+			url:    "https://example.com/?a=1;b=2&utm_medium=cpc",
+			params: Params{Medium: "email"},
+			want:   "https://example.com/?a=1;b=2&utm_medium=email",
+		},
+		{
+			name:   "escaped utm key is replaced",
+			url:    "https://example.com/?utm%5Fsource=old&id=7",
+			params: Params{Source: "new"},
+			want:   "https://example.com/?id=7&utm_source=new",
+		},
+		{
+			name:   "special characters are escaped",
+			url:    "https://example.com/",
+			params: Params{Term: "a&b=c#d"},
+			want:   "https://example.com/?utm_term=a%26b%3Dc%23d",
+		},
+		{
+			name:    "relative url",
+			url:     "/page",
+			params:  Params{Source: "x"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge(tt.url, tt.params)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidURL)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParamsOr(t *testing.T) {
+	p := Params{Source: "explicit", Term: "t"}.Or(Params{Source: "default", Medium: "email", Campaign: "c"})
+	assert.Equal(t, Params{Source: "explicit", Medium: "email", Campaign: "c", Term: "t"}, p)
+}